@@ -0,0 +1,75 @@
+package main
+
+// CSV/TSV input for legacy harnesses and spreadsheet exports without JSON output. The first
+// row holds the column headers and every following row is a test case, so the test case
+// settings (test_junit_list_name, test_junit_list_failure, test_junit_list_time, ...) refer
+// to column headers. Empty cells are treated as missing values. Rows are converted one at a
+// time while reading.
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ParseJunitCSV converts a CSV (or TSV when InputFormat is tsv) stream to JUnit.
+func ParseJunitCSV(reader io.Reader, settings Config) (*Testsuites, error) {
+	if settings.NestedJsonList {
+		return nil, fmt.Errorf("nested_json_list is not supported with %s input", settings.InputFormat)
+	}
+	if err := prepareMapping(settings); err != nil {
+		return nil, err
+	}
+
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+	if settings.InputFormat == InputFormatTSV {
+		csvReader.Comma = '\t'
+		csvReader.LazyQuotes = true
+	}
+
+	header, err := csvReader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%s content has no header row", settings.InputFormat)
+		}
+		return nil, fmt.Errorf("failed to parse %s header: %s", settings.InputFormat, err)
+	}
+	for i, column := range header {
+		header[i] = strings.TrimSpace(strings.TrimPrefix(column, "\ufeff"))
+	}
+
+	// Suite level settings can only be fixed values
+	testSuite := newTestSuite(nil, settings)
+	for {
+		row, err := csvReader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s content: %s", settings.InputFormat, err)
+		}
+		line, _ := csvReader.FieldPos(0)
+
+		record := map[string]interface{}{}
+		for i, value := range row {
+			if i < len(header) && value != "" {
+				record[header[i]] = value
+			}
+		}
+		if len(record) == 0 {
+			continue
+		}
+		redactFields(record, settings.RedactFields)
+		if err := testSuite.addTestCase(record, settings); err != nil {
+			return nil, fmt.Errorf("line %d: %s", line, err)
+		}
+	}
+
+	testSuites := &Testsuites{TestSuite: groupTestSuites([]Testsuite{testSuite}, settings)}
+	status = newStatus(testSuites)
+
+	return testSuites, nil
+}
@@ -0,0 +1,165 @@
+package main
+
+// Test times are written in seconds with fractions, as JUnit expects. Numeric values are
+// read in TimeUnit (s by default, or ns, us, ms, m, h) and converted; string values may
+// also be Go durations ("1.5s", "250ms") or ISO-8601 durations ("PT1M30.5S").
+//
+// When the suite time is not mapped it is aggregated from its test cases: the sum by
+// default, or the longest test case for suites that run in parallel.
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	TimeUnitNanoseconds  = "ns"
+	TimeUnitMicroseconds = "us"
+	TimeUnitMilliseconds = "ms"
+	TimeUnitSeconds      = "s"
+	TimeUnitMinutes      = "m"
+	TimeUnitHours        = "h"
+)
+
+const (
+	TimeAggregationSum  = "sum"
+	TimeAggregationMax  = "max"
+	TimeAggregationNone = "none"
+)
+
+// JUnit timestamps are ISO-8601 without time zone
+const junitTimestampLayout = "2006-01-02T15:04:05"
+
+// validateTimeAggregation checks the SuiteTimeAggregation setting.
+func validateTimeAggregation(aggregation string) error {
+	switch aggregation {
+	case "", TimeAggregationSum, TimeAggregationMax, TimeAggregationNone:
+		return nil
+	}
+	return fmt.Errorf("unknown suite time aggregation %q, expected sum, max or none", aggregation)
+}
+
+// suiteTimeAggregation returns the SuiteTimeAggregation setting, sum by default.
+func suiteTimeAggregation(settings Config) string {
+	if settings.SuiteTimeAggregation == "" {
+		return TimeAggregationSum
+	}
+	return settings.SuiteTimeAggregation
+}
+
+// resolveTimestamp resolves the suite timestamp. RFC 3339 strings and Unix epoch seconds
+// are converted to the JUnit layout, other values are kept as they are. Without a setting
+// the current time is used, an expression that resolves to nothing leaves it empty.
+func resolveTimestamp(data interface{}, path string) string {
+	value, ok := resolvePath(data, path)
+	if !ok {
+		if path == "" {
+			return time.Now().UTC().Format(junitTimestampLayout)
+		}
+		if !isLiteralFallback(path) {
+			return ""
+		}
+		value = path
+	}
+	if text, ok := value.(string); ok {
+		if parsed, err := time.Parse(time.RFC3339Nano, text); err == nil {
+			return parsed.UTC().Format(junitTimestampLayout)
+		}
+		return text
+	}
+	if epoch, ok := toFloat(value); ok {
+		return time.Unix(int64(epoch), 0).UTC().Format(junitTimestampLayout)
+	}
+	return stringify(value)
+}
+
+// validateTimeUnit checks the TimeUnit setting.
+func validateTimeUnit(unit string) error {
+	switch unit {
+	case "", TimeUnitNanoseconds, TimeUnitMicroseconds, TimeUnitMilliseconds, TimeUnitSeconds, TimeUnitMinutes, TimeUnitHours:
+		return nil
+	}
+	return fmt.Errorf("unknown time unit %q, expected ns, us, ms, s, m or h", unit)
+}
+
+// toSeconds converts a number expressed in unit to seconds.
+func toSeconds(value float64, unit string) float64 {
+	var seconds float64
+	switch unit {
+	case TimeUnitNanoseconds:
+		seconds = value / 1e9
+	case TimeUnitMicroseconds:
+		seconds = value / 1e6
+	case TimeUnitMilliseconds:
+		seconds = value / 1e3
+	case TimeUnitMinutes:
+		seconds = value * 60
+	case TimeUnitHours:
+		seconds = value * 3600
+	default:
+		seconds = value
+	}
+	// keep nanosecond precision without float noise such as 0.30000000000000004
+	return math.Round(seconds*1e9) / 1e9
+}
+
+// parseSeconds converts a resolved time value to seconds.
+func parseSeconds(value interface{}, unit string) (float64, bool) {
+	if text, ok := value.(string); ok {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			return 0, false
+		}
+		if number, err := strconv.ParseFloat(text, 64); err == nil {
+			return toSeconds(number, unit), true
+		}
+		if duration, err := time.ParseDuration(text); err == nil {
+			return duration.Seconds(), true
+		}
+		return parseISO8601Duration(text)
+	}
+	number, ok := toFloat(value)
+	if !ok {
+		return 0, false
+	}
+	return toSeconds(number, unit), true
+}
+
+// resolveSeconds resolves path against data as a time in seconds.
+func resolveSeconds(data interface{}, path string, unit string) (float64, bool) {
+	value, ok := resolvePath(data, path)
+	if !ok {
+		return 0, false
+	}
+	return parseSeconds(value, unit)
+}
+
+var iso8601DurationRegex = regexp.MustCompile(`^P(?:(\d+(?:[.,]\d+)?)Y)?(?:(\d+(?:[.,]\d+)?)M)?(?:(\d+(?:[.,]\d+)?)W)?(?:(\d+(?:[.,]\d+)?)D)?(?:T(?:(\d+(?:[.,]\d+)?)H)?(?:(\d+(?:[.,]\d+)?)M)?(?:(\d+(?:[.,]\d+)?)S)?)?$`)
+
+// parseISO8601Duration parses durations such as "PT1M30.5S" or "P1DT2H". Years and months
+// are approximated as 365 and 30 days.
+func parseISO8601Duration(text string) (float64, bool) {
+	matches := iso8601DurationRegex.FindStringSubmatch(strings.ToUpper(text))
+	if matches == nil || text == "P" || strings.HasSuffix(text, "T") {
+		return 0, false
+	}
+	units := []float64{365 * 86400, 30 * 86400, 7 * 86400, 86400, 3600, 60, 1}
+	seconds := 0.0
+	found := false
+	for i, unit := range units {
+		if matches[i+1] == "" {
+			continue
+		}
+		number, err := strconv.ParseFloat(strings.Replace(matches[i+1], ",", ".", 1), 64)
+		if err != nil {
+			return 0, false
+		}
+		seconds += number * unit
+		found = true
+	}
+	return math.Round(seconds*1e9) / 1e9, found
+}
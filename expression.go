@@ -0,0 +1,133 @@
+package main
+
+// FailWhen, SkipWhen and ErrorWhen are boolean expressions (https://expr-lang.org) evaluated
+// against each test case record, every field of the record is a variable:
+//
+//   --fail_when 'grade < 5'
+//   --skip_when 'status == "ignored" || len(comments) == 0'
+//   --error_when 'level in ["error", "fatal"]'
+//
+// SkipWhen is checked first, then ErrorWhen, then FailWhen, which replaces the presence of
+// TestJUnitListFailure as failure condition. Missing fields are nil: 'grade < 5' on a record
+// without grade does not match and logs a warning, use '(grade ?? 0) < 5' to compare a default
+// instead (or for missing nested fields such as 'check.grade'). CSV and TSV cells are
+// strings, so cells holding a number or true/false are typed before evaluating. Any other
+// expression that cannot be evaluated for a record, e.g. comparing a string with a number,
+// is a conversion error.
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/ast"
+	"github.com/expr-lang/expr/vm"
+)
+
+var expressionCache = map[string]*vm.Program{}
+
+// compileExpression parses a boolean expression and caches it.
+func compileExpression(code string) (*vm.Program, error) {
+	if program, ok := expressionCache[code]; ok {
+		return program, nil
+	}
+	program, err := expr.Compile(code, expr.AsBool(), expr.AllowUndefinedVariables())
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression %q: %s", code, err)
+	}
+	expressionCache[code] = program
+	return program, nil
+}
+
+// validateExpressions compiles every non empty expression setting.
+func validateExpressions(expressions map[string]string) error {
+	for setting, code := range expressions {
+		if code == "" {
+			continue
+		}
+		if _, err := compileExpression(code); err != nil {
+			return fmt.Errorf("%s: %s", setting, err)
+		}
+	}
+	return nil
+}
+
+var numberRegex = regexp.MustCompile(`^[+-]?(\d+\.?\d*|\.\d+)([eE][+-]?\d+)?$`)
+
+// evaluateExpression evaluates code against a record. ok is false when code is empty.
+func evaluateExpression(record interface{}, code string, inputFormat string) (bool, bool, error) {
+	if code == "" {
+		return false, false, nil
+	}
+	program, err := compileExpression(code)
+	if err != nil {
+		return false, false, err
+	}
+	env, isMap := record.(map[string]interface{})
+	if !isMap {
+		// lists and scalars are available as "record"
+		env = map[string]interface{}{"record": record}
+	} else if inputFormat == InputFormatCSV || inputFormat == InputFormatTSV {
+		env = typedCells(env)
+	}
+	result, err := expr.Run(program, env)
+	if err != nil {
+		// heterogeneous tool output: a record without the compared field does not match
+		if missing := missingFields(program, env); len(missing) > 0 {
+			logger.Warn("Expression uses fields missing from the record, not matched", "expression", code, "fields", strings.Join(missing, ","), "error", err)
+			return false, true, nil
+		}
+		return false, true, fmt.Errorf("failed to evaluate expression %q: %s", code, err)
+	}
+	matched, _ := result.(bool)
+	return matched, true, nil
+}
+
+type identifierVisitor struct {
+	names []string
+}
+
+func (visitor *identifierVisitor) Visit(node *ast.Node) {
+	if identifier, ok := (*node).(*ast.IdentifierNode); ok {
+		visitor.names = append(visitor.names, identifier.Value)
+	}
+}
+
+// missingFields returns the variables of a program that are missing or null in env.
+func missingFields(program *vm.Program, env map[string]interface{}) []string {
+	visitor := &identifierVisitor{}
+	node := program.Node()
+	ast.Walk(&node, visitor)
+	missing := []string{}
+	seen := map[string]bool{}
+	for _, name := range visitor.names {
+		if value, ok := env[name]; (!ok || value == nil) && !seen[name] {
+			seen[name] = true
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// typedCells returns a copy of a CSV record with numeric and true/false cells converted.
+func typedCells(record map[string]interface{}) map[string]interface{} {
+	typed := make(map[string]interface{}, len(record))
+	for key, value := range record {
+		typed[key] = value
+		cell, isString := value.(string)
+		if !isString {
+			continue
+		}
+		cell = strings.TrimSpace(cell)
+		if numberRegex.MatchString(cell) {
+			if number, err := strconv.ParseFloat(cell, 64); err == nil {
+				typed[key] = number
+			}
+		} else if strings.EqualFold(cell, "true") || strings.EqualFold(cell, "false") {
+			typed[key] = strings.EqualFold(cell, "true")
+		}
+	}
+	return typed
+}
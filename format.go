@@ -0,0 +1,167 @@
+package main
+
+// Input formats. JSON is the default; YAML (e.g. conftest -o yaml) and TOML documents are
+// decoded into the same structure as JSON, so every mapping setting works unchanged.
+// NDJSON (ndjson.go) and CSV/TSV (csv.go) are streamed record by record instead.
+// With "auto" the format is taken from the file extension, or sniffed from the content
+// when the extension is unknown or the input is JsonContent/stdin (CSV/TSV are only
+// detected by extension).
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	InputFormatJSON   = "json"
+	InputFormatNDJSON = "ndjson"
+	InputFormatYAML   = "yaml"
+	InputFormatTOML   = "toml"
+	InputFormatCSV    = "csv"
+	InputFormatTSV    = "tsv"
+	InputFormatAuto   = "auto"
+)
+
+// validateInputFormat checks the InputFormat setting.
+func validateInputFormat(format string) error {
+	switch format {
+	case "", InputFormatJSON, InputFormatNDJSON, InputFormatYAML, InputFormatTOML, InputFormatCSV, InputFormatTSV, InputFormatAuto:
+		return nil
+	}
+	return fmt.Errorf("unknown input format %q, expected json, ndjson, yaml, toml, csv, tsv or auto", format)
+}
+
+// isStreamingFormat reports whether the format is converted record by record.
+func isStreamingFormat(format string) bool {
+	return format == InputFormatNDJSON || format == InputFormatCSV || format == InputFormatTSV
+}
+
+// parseStream converts a streaming format (see isStreamingFormat) to JUnit.
+func parseStream(reader io.Reader, settings Config) (*Testsuites, error) {
+	if len(settings.SuiteHierarchy) > 0 {
+		return nil, fmt.Errorf("suite_hierarchy is not supported with %s input", settings.InputFormat)
+	}
+	switch settings.InputFormat {
+	case InputFormatCSV, InputFormatTSV:
+		return ParseJunitCSV(reader, settings)
+	default:
+		return ParseJunitNDJSON(reader, settings)
+	}
+}
+
+// formatFromExtension returns the input format of a file name, "" when unknown.
+func formatFromExtension(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".json":
+		return InputFormatJSON
+	case ".ndjson", ".jsonl":
+		return InputFormatNDJSON
+	case ".yaml", ".yml":
+		return InputFormatYAML
+	case ".toml":
+		return InputFormatTOML
+	case ".csv":
+		return InputFormatCSV
+	case ".tsv":
+		return InputFormatTSV
+	}
+	return ""
+}
+
+// sniffInputFormat guesses the format of content: JSON, then JSON Lines, then TOML and
+// finally YAML, which accepts almost anything.
+func sniffInputFormat(content []byte) string {
+	trimmed := bytes.TrimSpace(content)
+	if json.Valid(trimmed) {
+		return InputFormatJSON
+	}
+	if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') && isNDJSON(trimmed) {
+		return InputFormatNDJSON
+	}
+	var tomlDocument map[string]interface{}
+	if toml.Unmarshal(trimmed, &tomlDocument) == nil && len(tomlDocument) > 0 {
+		return InputFormatTOML
+	}
+	return InputFormatYAML
+}
+
+func isNDJSON(content []byte) bool {
+	for _, line := range bytes.Split(content, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 && !json.Valid(line) {
+			return false
+		}
+	}
+	return true
+}
+
+// decodeDocument decodes content in the given format into JSON compatible values
+// (map[string]interface{}, []interface{}, string, float64, bool and nil).
+func decodeDocument(content []byte, format string) (interface{}, error) {
+	var document interface{}
+	switch format {
+	case "", InputFormatJSON:
+		if err := json.Unmarshal(content, &document); err != nil {
+			return nil, fmt.Errorf("failed to parse JSON content: %s", err)
+		}
+		return document, nil
+	case InputFormatYAML:
+		if err := yaml.Unmarshal(content, &document); err != nil {
+			return nil, fmt.Errorf("failed to parse YAML content: %s", err)
+		}
+	case InputFormatTOML:
+		var tomlDocument map[string]interface{}
+		if err := toml.Unmarshal(content, &tomlDocument); err != nil {
+			return nil, fmt.Errorf("failed to parse TOML content: %s", err)
+		}
+		document = tomlDocument
+	default:
+		return nil, fmt.Errorf("cannot decode %s content as a single document", format)
+	}
+	return normalizeDocument(document), nil
+}
+
+// normalizeDocument converts YAML/TOML specific values (integer types, non string keys,
+// timestamps) into the values encoding/json would produce.
+func normalizeDocument(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = normalizeDocument(item)
+		}
+		return v
+	case map[interface{}]interface{}:
+		normalized := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			normalized[fmt.Sprint(key)] = normalizeDocument(item)
+		}
+		return normalized
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalizeDocument(item)
+		}
+		return v
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case fmt.Stringer:
+		// toml.LocalDate, LocalTime and LocalDateTime
+		return v.String()
+	}
+	return value
+}
@@ -0,0 +1,235 @@
+package main
+
+// The quality gate decides whether the step fails, looking at every suite of the report
+// (nested and grouped suites included):
+//
+//   - FailOnFailure      any failure or error
+//   - MaxFailures        the number of failures, e.g. 0
+//   - MaxErrors          the number of errors
+//   - MinScore           the score of the status table, 0 to 100; passes without executed cases
+//   - SeverityLimits     test cases per SeverityField value, skipped ones excluded, "error=0,warning=10"
+//   - MaxSuiteFailures   failures plus errors of any single test suite
+//
+// Negative limits are disabled, which is the default. The gate report lists every rule
+// with its limit and actual value, and a failed gate exits with the code of its first
+// failed rule, so a pipeline can tell a conversion error from a failed gate:
+//
+//   1  conversion error
+//   2  fail_on_errors, max_failures or max_errors
+//   3  min_score
+//   4  severity_limits
+//   5  max_suite_failures
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	ExitCodeGateFailures = 2
+	ExitCodeGateScore    = 3
+	ExitCodeGateSeverity = 4
+	ExitCodeGateSuite    = 5
+)
+
+type (
+	GateResult struct {
+		Rule   string
+		Limit  string
+		Actual string
+		Passed bool
+
+		exitCode int
+	}
+	// GateError is returned by Exec when a rule of the quality gate failed.
+	GateError struct {
+		Code   int
+		Failed []GateResult
+	}
+)
+
+func (err *GateError) Error() string {
+	rules := []string{}
+	for _, result := range err.Failed {
+		rules = append(rules, result.Rule+" ("+result.Actual+", limit "+result.Limit+")")
+	}
+	return "error: quality gate failed: " + strings.Join(rules, ", ")
+}
+
+// ParseSeverityLimits parses "severity=max" pairs, e.g. "error=0,warning=10".
+func ParseSeverityLimits(value string) (map[string]int, error) {
+	limits := map[string]int{}
+	entries, err := ParseProperties(value)
+	if err != nil {
+		return nil, err
+	}
+	for severity, limit := range entries {
+		max, err := strconv.Atoi(limit)
+		if err != nil || max < 0 {
+			return nil, fmt.Errorf("invalid limit %q for severity %q, expected a number of test cases", limit, severity)
+		}
+		limits[strings.ToLower(severity)] = max
+	}
+	return limits, nil
+}
+
+// formatSeverityLimits renders severity limits back to the "severity=max" form.
+func formatSeverityLimits(limits map[string]int) string {
+	entries := []string{}
+	for severity, limit := range limits {
+		entries = append(entries, severity+"="+strconv.Itoa(limit))
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, ",")
+}
+
+// validateGate checks the quality gate settings.
+func validateGate(settings Config) error {
+	if settings.MinScore > 100 {
+		return fmt.Errorf("min_score %v is above 100", settings.MinScore)
+	}
+	if len(settings.SeverityLimits) > 0 && settings.SeverityField == "" {
+		return fmt.Errorf("severity_limits requires severity_field")
+	}
+	return nil
+}
+
+// evaluateGate checks every enabled rule of the quality gate against the report.
+func evaluateGate(junitReport *Testsuites, status Status, settings Config) []GateResult {
+	results := []GateResult{}
+	if settings.FailOnFailure {
+		results = append(results, countRule("fail_on_errors", status.Failures+status.Errors, 0, ExitCodeGateFailures))
+	}
+	if settings.MaxFailures >= 0 {
+		results = append(results, countRule("max_failures", status.Failures, settings.MaxFailures, ExitCodeGateFailures))
+	}
+	if settings.MaxErrors >= 0 {
+		results = append(results, countRule("max_errors", status.Errors, settings.MaxErrors, ExitCodeGateFailures))
+	}
+	if settings.MinScore >= 0 {
+		result := GateResult{Rule: "min_score", Limit: strconv.FormatFloat(settings.MinScore, 'f', 2, 64), Passed: true, exitCode: ExitCodeGateScore}
+		if status.Total-status.Skipped > 0 {
+			result.Actual = strconv.FormatFloat(status.Score, 'f', 2, 64)
+			result.Passed = status.Score >= settings.MinScore
+		} else {
+			result.Actual = "no executed test cases"
+		}
+		results = append(results, result)
+	}
+
+	if len(settings.SeverityLimits) > 0 {
+		counts := map[string]int{}
+		countSeverities(junitReport.TestSuite, counts)
+		severities := []string{}
+		for severity := range settings.SeverityLimits {
+			severities = append(severities, severity)
+		}
+		sort.Strings(severities)
+		for _, severity := range severities {
+			results = append(results, countRule("severity "+severity, counts[severity], settings.SeverityLimits[severity], ExitCodeGateSeverity))
+		}
+	}
+
+	if settings.MaxSuiteFailures >= 0 {
+		for _, suite := range leafSuites(junitReport.TestSuite, "") {
+			failures := suite.testSuite.Failures + suite.testSuite.Errors
+			results = append(results, countRule("suite "+suite.name, failures, settings.MaxSuiteFailures, ExitCodeGateSuite))
+		}
+	}
+	return results
+}
+
+func countRule(rule string, actual int, limit int, exitCode int) GateResult {
+	return GateResult{
+		Rule:     rule,
+		Limit:    strconv.Itoa(limit),
+		Actual:   strconv.Itoa(actual),
+		Passed:   actual <= limit,
+		exitCode: exitCode,
+	}
+}
+
+// countSeverities counts the test cases of every severity, skipped test cases excluded.
+func countSeverities(testSuites []Testsuite, counts map[string]int) {
+	for _, testSuite := range testSuites {
+		countSeverities(testSuite.TestSuite, counts)
+		for _, testCase := range testSuite.TestCase {
+			if testCase.severity != "" && testCase.Skipped == nil {
+				counts[strings.ToLower(testCase.severity)]++
+			}
+		}
+	}
+}
+
+type namedSuite struct {
+	name      string
+	testSuite *Testsuite
+}
+
+// leafSuites returns the suites holding test cases, named after their parents when nested.
+func leafSuites(testSuites []Testsuite, parent string) []namedSuite {
+	suites := []namedSuite{}
+	for i := range testSuites {
+		testSuite := &testSuites[i]
+		name := testSuite.Name
+		if parent != "" {
+			name = parent + "." + name
+		}
+		if len(testSuite.TestCase) > 0 || len(testSuite.TestSuite) == 0 {
+			suites = append(suites, namedSuite{name: name, testSuite: testSuite})
+		}
+		suites = append(suites, leafSuites(testSuite.TestSuite, name)...)
+	}
+	return suites
+}
+
+// gateError returns a GateError for the failed rules, nil when the gate passed.
+func gateError(results []GateResult) error {
+	gateErr := &GateError{}
+	for _, result := range results {
+		if result.Passed {
+			continue
+		}
+		if gateErr.Code == 0 || result.exitCode < gateErr.Code {
+			gateErr.Code = result.exitCode
+		}
+		gateErr.Failed = append(gateErr.Failed, result)
+	}
+	if len(gateErr.Failed) == 0 {
+		return nil
+	}
+	return gateErr
+}
+
+// logGate reports the quality gate, as a table or as info log records.
+func logGate(results []GateResult) {
+	if len(results) == 0 {
+		return
+	}
+	if !printTables {
+		for _, result := range results {
+			logger.Info("Quality gate rule", "rule", result.Rule, "limit", result.Limit, "actual", result.Actual, "passed", result.Passed)
+		}
+		logger.Info("Quality gate", "passed", gateError(results) == nil)
+		return
+	}
+	fmt.Fprintln(console, "|----------------------------------|")
+	fmt.Fprintln(console, "|          Quality Gate            |")
+	fmt.Fprintln(console, "|----------------------------------|")
+	for _, result := range results {
+		verdict := "PASS"
+		if !result.Passed {
+			verdict = "FAIL"
+		}
+		fmt.Fprintf(console, "  %s  %s: %s (limit %s)\n", verdict, result.Rule, result.Actual, result.Limit)
+	}
+	fmt.Fprintln(console, "|----------------------------------|")
+	if err, ok := gateError(results).(*GateError); ok {
+		fmt.Fprintf(console, "  Result:  FAILED (exit code %d)\n", err.Code)
+	} else {
+		fmt.Fprintln(console, "  Result:  PASSED")
+	}
+	fmt.Fprintln(console, "|----------------------------------|")
+}
@@ -2,13 +2,18 @@ module harness-junit-converter
 
 go 1.21
 
-require github.com/urfave/cli v1.22.14
+require (
+	github.com/PaesslerAG/gval v1.0.0
+	github.com/PaesslerAG/jsonpath v0.1.1
+	github.com/expr-lang/expr v1.17.8
+	github.com/itchyny/gojq v0.12.17
+	github.com/pelletier/go-toml/v2 v2.2.2
+	github.com/urfave/cli v1.22.14
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
 	github.com/cpuguy83/go-md2man/v2 v2.0.2 // indirect
-	github.com/joho/godotenv v1.5.1 // indirect
-	github.com/pelletier/go-toml v1.9.5 // indirect
+	github.com/itchyny/timefmt-go v0.1.6 // indirect
 	github.com/russross/blackfriday/v2 v2.1.0 // indirect
-	github.com/sirupsen/logrus v1.9.3 // indirect
-	golang.org/x/sys v0.11.0 // indirect
 )
@@ -0,0 +1,88 @@
+package main
+
+// GroupBy partitions the test cases of a suite into one suite per value of one or more
+// fields, e.g. "file" for hadolint or "file,level", so a flat list of findings can be
+// browsed per file. Each group is named after the values of its fields, joined with " - "
+// and prefixed with the suite name when there is one; cases without any of the fields stay
+// in a suite with the original name.
+
+import (
+	"strings"
+)
+
+// ParseGroupBy parses a comma separated list of fields.
+func ParseGroupBy(value string) []string {
+	fields := []string{}
+	for _, field := range strings.Split(value, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// groupKey returns the values of the GroupBy fields of a test case record.
+func groupKey(record interface{}, fields []string) string {
+	values := []string{}
+	for _, field := range fields {
+		if value, ok := resolveString(record, field); ok && value != "" {
+			values = append(values, value)
+		}
+	}
+	return strings.Join(values, " - ")
+}
+
+// groupTestSuites replaces every suite (nested suites included) by one suite per group of
+// its test cases, in the order the groups first appear. Suites holding nested suites are
+// kept as they are.
+func groupTestSuites(testSuites []Testsuite, settings Config) []Testsuite {
+	if len(settings.GroupBy) == 0 {
+		return testSuites
+	}
+	grouped := []Testsuite{}
+	for _, testSuite := range testSuites {
+		testSuite.TestSuite = groupTestSuites(testSuite.TestSuite, settings)
+		if len(testSuite.TestCase) == 0 || len(testSuite.TestSuite) > 0 {
+			grouped = append(grouped, testSuite)
+			continue
+		}
+
+		groups := map[string]int{}
+		for _, testCase := range testSuite.TestCase {
+			index, ok := groups[testCase.group]
+			if !ok {
+				index = len(grouped)
+				groups[testCase.group] = index
+				grouped = append(grouped, newGroupSuite(testSuite, testCase.group))
+			}
+			grouped[index].appendTestCase(testCase)
+		}
+	}
+	return grouped
+}
+
+// newGroupSuite creates an empty copy of testSuite for the group key.
+func newGroupSuite(testSuite Testsuite, key string) Testsuite {
+	group := Testsuite{
+		Name:            testSuite.Name,
+		Package:         testSuite.Package,
+		Timestamp:       testSuite.Timestamp,
+		Hostname:        testSuite.Hostname,
+		Properties:      testSuite.Properties,
+		SystemOut:       testSuite.SystemOut,
+		SystemErr:       testSuite.SystemErr,
+		timeAggregation: testSuite.timeAggregation,
+	}
+	switch {
+	case key == "":
+	case group.Name == "":
+		group.Name = key
+	default:
+		group.Name += " - " + key
+	}
+	// a mapped suite time covers every group, the groups add up their test cases
+	if group.timeAggregation == "" {
+		group.timeAggregation = TimeAggregationSum
+	}
+	return group
+}
@@ -0,0 +1,175 @@
+package main
+
+// A suite hierarchy describes any number of lists above the test cases, e.g. a report
+// with files[] → rules[] → findings[]:
+//
+//   --suite_hierarchy "files=path > rules=id" --test_junit_list findings
+//
+// Each level is a list, resolved against the records of the level above (the document for
+// the first level), optionally followed by "=name", the setting that names the suites of
+// the level (a literal value or the list and index when it does not resolve). The test
+// cases are read with TestJUnitList from the records of the last level.
+//
+// With SuiteHierarchyStyle "dotted" (default) every record of the last level becomes a
+// test suite named after its ancestors, e.g. "Dockerfile.DL3018". With "nested" the
+// levels are written as nested <testsuite> elements, each one counting the test cases of
+// its children, below a single suite built from the suite settings.
+//
+// Levels are split on ">" and the name on the last "=", so lists that need a JSONPath
+// filter or a jq comparison are better described in a mapping file.
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	SuiteHierarchyDotted = "dotted"
+	SuiteHierarchyNested = "nested"
+)
+
+// SuiteLevel is one level of a suite hierarchy.
+type SuiteLevel struct {
+	List string `yaml:"list"`
+	Name string `yaml:"name"`
+}
+
+// ParseSuiteHierarchy parses a "list=name > list=name" hierarchy. "→" is accepted as separator too.
+func ParseSuiteHierarchy(value string) ([]SuiteLevel, error) {
+	levels := []SuiteLevel{}
+	for _, entry := range strings.Split(strings.ReplaceAll(value, "→", ">"), ">") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		level := SuiteLevel{List: entry}
+		if i := strings.LastIndex(entry, "="); i >= 0 {
+			level = SuiteLevel{List: strings.TrimSpace(entry[:i]), Name: strings.TrimSpace(entry[i+1:])}
+		}
+		if level.List == "" {
+			return nil, fmt.Errorf("invalid suite level %q, expected list or list=name", entry)
+		}
+		levels = append(levels, level)
+	}
+	return levels, nil
+}
+
+// formatSuiteHierarchy renders a suite hierarchy back to the "list=name > list=name" form.
+func formatSuiteHierarchy(levels []SuiteLevel) string {
+	entries := []string{}
+	for _, level := range levels {
+		if level.Name == "" {
+			entries = append(entries, level.List)
+		} else {
+			entries = append(entries, level.List+"="+level.Name)
+		}
+	}
+	return strings.Join(entries, " > ")
+}
+
+// validateSuiteHierarchy checks the SuiteHierarchy and SuiteHierarchyStyle settings.
+func validateSuiteHierarchy(levels []SuiteLevel, style string) error {
+	for i, level := range levels {
+		if level.List == "" {
+			return fmt.Errorf("suite level %d has no list", i+1)
+		}
+	}
+	switch style {
+	case "", SuiteHierarchyDotted, SuiteHierarchyNested:
+		return nil
+	}
+	return fmt.Errorf("unknown suite hierarchy style %q, expected %s or %s", style, SuiteHierarchyDotted, SuiteHierarchyNested)
+}
+
+// parseSuiteHierarchy converts a document described by SuiteHierarchy into test suites.
+func parseSuiteHierarchy(document interface{}, settings Config) ([]Testsuite, error) {
+	root := newTestSuite(document, settings)
+	if settings.SuiteHierarchyStyle == SuiteHierarchyNested {
+		children, err := parseSuiteLevels(document, settings.SuiteHierarchy, nil, root, settings)
+		if err != nil {
+			return nil, err
+		}
+		for _, child := range children {
+			root.addTestSuite(child)
+		}
+		return []Testsuite{root}, nil
+	}
+
+	names := []string{}
+	if root.Name != "" {
+		names = append(names, root.Name)
+	}
+	return parseSuiteLevels(document, settings.SuiteHierarchy, names, root, settings)
+}
+
+// parseSuiteLevels converts the records of the first level into test suites, names holds
+// the names of the ancestors for dotted suite names.
+func parseSuiteLevels(record interface{}, levels []SuiteLevel, names []string, root Testsuite, settings Config) ([]Testsuite, error) {
+	level := levels[0]
+	list, ok := resolveList(record, level.List)
+	if !ok {
+		return nil, fmt.Errorf("failed to parse suite level %q as a list", level.List)
+	}
+
+	testSuites := []Testsuite{}
+	for i, item := range list {
+		name := resolveStringOr(item, level.Name, level.Name)
+		if name == "" {
+			name = fmt.Sprintf("%s[%d]", level.List, i)
+		}
+		path := append(names[:len(names):len(names)], name)
+
+		nested := settings.SuiteHierarchyStyle == SuiteHierarchyNested
+		if !nested {
+			name = strings.Join(path, ".")
+		}
+
+		if len(levels) > 1 {
+			children, err := parseSuiteLevels(item, levels[1:], path, root, settings)
+			if err != nil {
+				return nil, err
+			}
+			if !nested {
+				testSuites = append(testSuites, children...)
+				continue
+			}
+			testSuite := newLevelSuite(name, root, settings)
+			for _, child := range children {
+				testSuite.addTestSuite(child)
+			}
+			testSuites = append(testSuites, testSuite)
+			continue
+		}
+
+		logger.Debug("Suite", "name", name)
+		testSuite := newLevelSuite(name, root, settings)
+		if err := testSuite.addTestCaseList(item, settings); err != nil {
+			return nil, err
+		}
+		testSuites = append(testSuites, testSuite)
+	}
+	return testSuites, nil
+}
+
+// newLevelSuite creates an empty Testsuite for a level of the hierarchy, its time is
+// aggregated from its test cases or child suites.
+func newLevelSuite(name string, root Testsuite, settings Config) Testsuite {
+	return Testsuite{
+		Name:            name,
+		Package:         root.Package,
+		Timestamp:       root.Timestamp,
+		Hostname:        root.Hostname,
+		Properties:      root.Properties,
+		timeAggregation: suiteTimeAggregation(settings),
+	}
+}
+
+// addTestSuite nests a child suite and adds up its counters.
+func (testSuite *Testsuite) addTestSuite(child Testsuite) {
+	testSuite.TestSuite = append(testSuite.TestSuite, child)
+	testSuite.Tests += child.Tests
+	testSuite.Failures += child.Failures
+	testSuite.Errors += child.Errors
+	testSuite.Skipped += child.Skipped
+	testSuite.aggregateTime(child.Time)
+}
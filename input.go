@@ -0,0 +1,111 @@
+package main
+
+// JsonFileName accepts a single path, "-" for stdin, or a glob pattern. Besides the usual
+// *, ? and [...] wildcards, "**" matches any number of directories, so "reports/**/*.json"
+// converts every JSON report below reports/. Each file becomes its own test suite.
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const StdinFileName = "-"
+
+// ExpandInputs returns the files matched by pattern, sorted by path.
+func ExpandInputs(pattern string) ([]string, error) {
+	if pattern == StdinFileName || !hasGlobMeta(pattern) {
+		return []string{pattern}, nil
+	}
+
+	var files []string
+	if strings.Contains(pattern, "**") {
+		root := globRoot(pattern)
+		err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !entry.IsDir() && matchGlob(filepath.ToSlash(pattern), filepath.ToSlash(path)) {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		files = matches
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no files match %s", pattern)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// OpenInput opens a file for streaming, "-" is stdin.
+func OpenInput(filename string) (io.ReadCloser, error) {
+	if filename == StdinFileName {
+		return io.NopCloser(os.Stdin), nil
+	}
+	return os.Open(filename)
+}
+
+func hasGlobMeta(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[")
+}
+
+// globRoot returns the directory before the first segment with a wildcard.
+func globRoot(pattern string) string {
+	segments := strings.Split(filepath.ToSlash(pattern), "/")
+	root := []string{}
+	for _, segment := range segments {
+		if hasGlobMeta(segment) {
+			break
+		}
+		root = append(root, segment)
+	}
+	if len(root) == 0 {
+		return "."
+	}
+	if len(root) == 1 && root[0] == "" {
+		return "/"
+	}
+	return filepath.FromSlash(strings.Join(root, "/"))
+}
+
+// matchGlob matches a slash separated path against a pattern where "**" matches
+// zero or more path segments.
+func matchGlob(pattern, path string) bool {
+	return matchSegments(strings.Split(filepath.Clean(pattern), "/"), strings.Split(filepath.Clean(path), "/"))
+}
+
+func matchSegments(pattern, path []string) bool {
+	if len(pattern) == 0 {
+		return len(path) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(path); i++ {
+			if matchSegments(pattern[1:], path[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(path) == 0 {
+		return false
+	}
+	if ok, _ := filepath.Match(pattern[0], path[0]); !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], path[1:])
+}
@@ -0,0 +1,77 @@
+package main
+
+// With --mapping_engine=jq every mapping setting is a jq program evaluated in-process
+// with gojq instead of a key name or JSONPath expression, e.g.
+//
+//   test_junit_name: '.meta.name // "Lint"'
+//   test_junit_list: '[.files[] | .findings[]]'
+//   test_junit_list_failure: 'select(.level == "error") | .message'
+//
+// A program that produces several values is treated like a JSONPath wildcard: all values are
+// collected into a list. A program that produces no value, null or an error means "not found".
+// Fixed values must be written as jq literals, e.g. '"My Suite"' or '10'.
+
+import (
+	"fmt"
+
+	"github.com/itchyny/gojq"
+)
+
+var jqCache = map[string]*gojq.Code{}
+
+// compileJq parses and compiles a jq program and caches the result.
+func compileJq(program string) (*gojq.Code, error) {
+	if code, ok := jqCache[program]; ok {
+		return code, nil
+	}
+	query, err := gojq.Parse(program)
+	if err != nil {
+		return nil, fmt.Errorf("invalid jq program %q: %s", program, err)
+	}
+	code, err := gojq.Compile(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid jq program %q: %s", program, err)
+	}
+	jqCache[program] = code
+	return code, nil
+}
+
+// resolveJq runs program against data and returns its output.
+func resolveJq(data interface{}, program string) (interface{}, bool) {
+	outputs, ok := resolveJqOutputs(data, program)
+	if !ok {
+		return nil, false
+	}
+	switch len(outputs) {
+	case 0:
+		return nil, false
+	case 1:
+		return outputs[0], true
+	default:
+		return outputs, true
+	}
+}
+
+// resolveJqOutputs runs program against data and returns every non null output.
+// ok is false when the program does not compile or fails at runtime.
+func resolveJqOutputs(data interface{}, program string) ([]interface{}, bool) {
+	code, err := compileJq(program)
+	if err != nil {
+		return nil, false
+	}
+	outputs := []interface{}{}
+	iter := code.Run(data)
+	for {
+		value, ok := iter.Next()
+		if !ok {
+			break
+		}
+		if _, isErr := value.(error); isErr {
+			return nil, false
+		}
+		if value != nil {
+			outputs = append(outputs, value)
+		}
+	}
+	return outputs, true
+}
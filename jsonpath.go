@@ -0,0 +1,281 @@
+package main
+
+// Every mapping setting (test_junit_name, test_junit_list, test_junit_list_failure, ...)
+// goes through resolvePath, so the same syntax is accepted everywhere:
+//
+//   - "."                        the current record itself (e.g. a top level JSON list)
+//   - "name"                     a single key of the current record
+//   - "comments[].summary"       legacy list syntax, same as "$.comments[*].summary"
+//   - "$.report.suites[*].cases" a JSONPath expression (wildcards, indices, slices,
+//                                recursive descent and filters like "$.checks[?(@.grade < 5)]")
+//
+// Suite level settings are evaluated against the JSON document (or the suite record when
+// NestedJsonList is true), test case settings are evaluated against each test case record.
+// "@" is accepted as an alias of "$" because the expression always starts at the current record,
+// keys starting with "$" or "@" (e.g. "@timestamp") are plain keys.
+//
+// With MappingEngine "jq" the same functions evaluate jq programs instead (see jq.go), and
+// settings containing "{{" are text/templates rendered against the record (see template.go).
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/PaesslerAG/gval"
+	"github.com/PaesslerAG/jsonpath"
+)
+
+const (
+	MappingEngineJSONPath = "jsonpath"
+	MappingEngineJq       = "jq"
+)
+
+// mappingEngine is the engine used by resolvePath, set by ParseJunit from Config.MappingEngine.
+var mappingEngine = MappingEngineJSONPath
+
+var jsonPathLanguage = gval.Full(jsonpath.Language())
+
+var jsonPathCache = map[string]gval.Evaluable{}
+
+// isJSONPath reports whether path is a JSONPath expression instead of a plain key: "$" or
+// "@" alone or followed by "." or "[". Keys like "@timestamp" stay plain keys.
+func isJSONPath(path string) bool {
+	path = strings.TrimSpace(path)
+	if !strings.HasPrefix(path, "$") && !strings.HasPrefix(path, "@") {
+		return false
+	}
+	return len(path) == 1 || path[1] == '.' || path[1] == '['
+}
+
+// isPlainKey reports whether path is a single key name (JSONPath engine only).
+func isPlainKey(path string) bool {
+	return mappingEngine != MappingEngineJq && path != "." && !isJSONPath(path) && !strings.Contains(path, "[]") && !isTemplate(path)
+}
+
+// setMappingEngine selects the engine used to resolve mapping settings.
+func setMappingEngine(engine string) error {
+	switch engine {
+	case "", MappingEngineJSONPath:
+		mappingEngine = MappingEngineJSONPath
+	case MappingEngineJq:
+		mappingEngine = MappingEngineJq
+	default:
+		return fmt.Errorf("unknown mapping engine %q, expected %s or %s", engine, MappingEngineJSONPath, MappingEngineJq)
+	}
+	return nil
+}
+
+// normalizePath converts any supported mapping syntax into a JSONPath expression.
+func normalizePath(path string) string {
+	path = strings.TrimSpace(path)
+	switch {
+	case path == ".":
+		return "$"
+	case isJSONPath(path) && strings.HasPrefix(path, "@"):
+		return "$" + path[1:]
+	case isJSONPath(path):
+		return path
+	case strings.Contains(path, "[]"):
+		return "$." + strings.ReplaceAll(path, "[]", "[*]")
+	default:
+		return "$[" + strconv.Quote(path) + "]"
+	}
+}
+
+// compilePath parses a mapping setting and caches the compiled expression.
+func compilePath(path string) (gval.Evaluable, error) {
+	expression := normalizePath(path)
+	if eval, ok := jsonPathCache[expression]; ok {
+		return eval, nil
+	}
+	eval, err := jsonPathLanguage.NewEvaluable(expression)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSONPath %q: %s", path, err)
+	}
+	jsonPathCache[expression] = eval
+	return eval, nil
+}
+
+// validatePaths compiles every non empty mapping setting so syntax errors are reported
+// before any conversion happens.
+func validatePaths(paths map[string]string) error {
+	for setting, path := range paths {
+		if path == "" {
+			continue
+		}
+		var err error
+		if isTemplate(path) {
+			_, err = compileTemplate(path)
+		} else if mappingEngine == MappingEngineJq {
+			_, err = compileJq(path)
+		} else {
+			_, err = compilePath(path)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %s", setting, err)
+		}
+	}
+	return nil
+}
+
+// resolvePath evaluates path against data. Missing keys are not an error: ok is false.
+func resolvePath(data interface{}, path string) (interface{}, bool) {
+	if isTemplate(path) {
+		return renderTemplate(data, path)
+	}
+	if path == "" || data == nil {
+		return nil, false
+	}
+	if mappingEngine == MappingEngineJq {
+		return resolveJq(data, path)
+	}
+	eval, err := compilePath(path)
+	if err != nil {
+		return nil, false
+	}
+	value, err := eval(context.Background(), data)
+	if err != nil || value == nil {
+		return nil, false
+	}
+	return value, true
+}
+
+// resolveString resolves path and converts the result to a string.
+func resolveString(data interface{}, path string) (string, bool) {
+	value, ok := resolvePath(data, path)
+	if !ok {
+		return "", false
+	}
+	return stringify(value), true
+}
+
+// resolveStringOr resolves path and falls back to the given literal value. Only a plain key
+// falls back, JSONPath expressions, jq programs and templates are empty when they resolve
+// to nothing.
+func resolveStringOr(data interface{}, path string, fallback string) string {
+	value, ok := resolveString(data, path)
+	if !ok || value == "" {
+		if !isLiteralFallback(path) {
+			return ""
+		}
+		return fallback
+	}
+	return value
+}
+
+// isLiteralFallback reports whether an unresolved setting is used as a fixed value, e.g.
+// --test_junit_name "My Suite". jq fixed values are jq literals instead (see jq.go).
+func isLiteralFallback(path string) bool {
+	return isPlainKey(path)
+}
+
+// resolveBool resolves path as a boolean. "true"/"false" strings are accepted as well.
+func resolveBool(data interface{}, path string) (bool, bool) {
+	value, ok := resolvePath(data, path)
+	if !ok {
+		return false, false
+	}
+	switch v := value.(type) {
+	case bool:
+		return v, true
+	case string:
+		b, err := strconv.ParseBool(v)
+		return b, err == nil
+	case []interface{}:
+		// wildcard expressions return all matches, any true match wins
+		for _, item := range v {
+			if b, ok := item.(bool); ok && b {
+				return true, true
+			}
+		}
+		return false, len(v) > 0
+	}
+	return false, false
+}
+
+// resolveList resolves path as a list. The matches of a wildcard expression (or the outputs of
+// a jq program) that are lists themselves (e.g. "$.suites[*].cases") are flattened into a single list.
+func resolveList(data interface{}, path string) ([]interface{}, bool) {
+	var list []interface{}
+	if mappingEngine == MappingEngineJq {
+		// every output of the program is an element, so ".items[] | select(...)" works too
+		outputs, ok := resolveJqOutputs(data, path)
+		if !ok || data == nil {
+			return nil, false
+		}
+		list = outputs
+	} else {
+		value, ok := resolvePath(data, path)
+		if !ok {
+			return nil, false
+		}
+		list, ok = value.([]interface{})
+		if !ok {
+			return nil, false
+		}
+	}
+	if isPlainKey(path) {
+		return list, true
+	}
+	flattened := []interface{}{}
+	for _, item := range list {
+		if nested, ok := item.([]interface{}); ok {
+			flattened = append(flattened, nested...)
+		} else {
+			flattened = append(flattened, item)
+		}
+	}
+	return flattened, true
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// stringify converts a resolved value to text. Lists (e.g. every comments[].summary)
+// are joined with "; ", objects are rendered as JSON.
+func stringify(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case []interface{}:
+		parts := []string{}
+		for _, item := range v {
+			if s := stringify(item); s != "" {
+				parts = append(parts, s)
+			}
+		}
+		return strings.Join(parts, "; ")
+	default:
+		content, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(content)
+	}
+}
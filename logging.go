@@ -0,0 +1,91 @@
+package main
+
+// Diagnostics go through a leveled logger instead of plain prints, with secrets redacted
+// from every attribute (see redact.go):
+//
+//   - error  conversion errors only
+//   - warn   values that could not be used, e.g. an unparsable time or an unmapped status
+//   - info   (default) the banner, config and results tables and the files read and written
+//   - debug  every suite and test case record, for mapping troubleshooting
+//
+// LogFormat "json" writes one JSON object per line for log processors, with the tables
+// left out and the status logged as an info record; "text" (default) writes key=value
+// pairs. The status and quality gate tables are printed at the info level only, so
+// normal runs at the warn or error level stay quiet.
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+var logger = slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+// printTables is false when the output is JSON lines or the info level is disabled.
+var printTables = true
+
+// setupLogging configures the logger from the LogLevel and LogFormat settings.
+func setupLogging(level string, format string, writer io.Writer) error {
+	var logLevel slog.Level
+	switch strings.ToLower(level) {
+	case "error":
+		logLevel = slog.LevelError
+	case "warn", "warning":
+		logLevel = slog.LevelWarn
+	case "", "info":
+		logLevel = slog.LevelInfo
+	case "debug":
+		logLevel = slog.LevelDebug
+	default:
+		return fmt.Errorf("unknown log level %q, expected error, warn, info or debug", level)
+	}
+
+	options := &slog.HandlerOptions{Level: logLevel, ReplaceAttr: redactAttr}
+	switch strings.ToLower(format) {
+	case "", LogFormatText:
+		logger = slog.New(slog.NewTextHandler(writer, options))
+		printTables = logLevel <= slog.LevelInfo
+	case LogFormatJSON:
+		logger = slog.New(slog.NewJSONHandler(writer, options))
+		printTables = false
+	default:
+		return fmt.Errorf("unknown log format %q, expected %s or %s", format, LogFormatText, LogFormatJSON)
+	}
+	return nil
+}
+
+// logStatus reports the JUnit status, as a table or as an info log record.
+func logStatus(status Status) {
+	if printTables {
+		printStatusTable(status)
+		return
+	}
+	logger.Info("JUnit status",
+		"total", status.Total, "passed", status.Passed, "failures", status.Failures,
+		"errors", status.Errors, "skipped", status.Skipped, "score", status.Score)
+}
+
+// redactAttr keeps secrets out of the logs: every string attribute, the message and records
+// (logged as their JSON text) go through redactText.
+func redactAttr(groups []string, attr slog.Attr) slog.Attr {
+	switch attr.Value.Kind() {
+	case slog.KindString:
+		return slog.String(attr.Key, redactText(attr.Value.String()))
+	case slog.KindAny:
+		if len(groups) == 0 && attr.Key == slog.LevelKey {
+			return attr
+		}
+		if err, ok := attr.Value.Any().(error); ok {
+			return slog.String(attr.Key, redactText(err.Error()))
+		}
+		return slog.String(attr.Key, redactText(stringify(attr.Value.Any())))
+	}
+	return attr
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
@@ -38,7 +39,7 @@ func main() {
 		},
 		cli.StringFlag{
 			Name:   "test_junit_time",
-			Usage:  "JUnit time.",
+			Usage:  "JUnit time (key, JSONPath or fixed value).",
 			EnvVar: "PLUGIN_TEST_JUNIT_TIME",
 		},
 		cli.StringFlag{
@@ -48,34 +49,114 @@ func main() {
 		},
 		cli.StringFlag{
 			Name:   "test_junit_name",
-			Usage:  "JUnit name.",
+			Usage:  "JUnit name (key, JSONPath or fixed value).",
 			EnvVar: "PLUGIN_TEST_JUNIT_NAME",
 		},
 		cli.StringSliceFlag{
 			Name:   "test_junit_list",
-			Usage:  "List of JUnit tests.",
+			Usage:  "List of JUnit tests (key, JSONPath or \".\" for a JSON list).",
 			EnvVar: "PLUGIN_TEST_JUNIT_LIST",
 		},
 		cli.StringFlag{
 			Name:   "test_junit_list_name",
-			Usage:  "Name for JUnit list.",
+			Usage:  "Name for JUnit list (key or JSONPath).",
 			EnvVar: "PLUGIN_TEST_JUNIT_LIST_NAME",
 		},
 		cli.StringFlag{
 			Name:   "test_junit_list_class_name",
-			Usage:  "Class name for JUnit list.",
+			Usage:  "Class name for JUnit list (key or JSONPath).",
 			EnvVar: "PLUGIN_TEST_JUNIT_LIST_CLASS_NAME",
 		},
 		cli.StringFlag{
 			Name:   "test_junit_list_failure",
-			Usage:  "Failure message for JUnit list.",
+			Usage:  "Failure message for JUnit list (key or JSONPath).",
 			EnvVar: "PLUGIN_TEST_JUNIT_LIST_FAILURE",
 		},
 		cli.StringFlag{
 			Name:   "test_junit_list_time",
-			Usage:  "Time for each JUnit list test.",
+			Usage:  "Time for each JUnit list test (key, JSONPath or fixed value).",
 			EnvVar: "PLUGIN_TEST_JUNIT_LIST_TIME",
 		},
+		cli.StringFlag{
+			Name:   "time_unit",
+			Usage:  "Unit of numeric times: ns, us, ms, s (default), m or h.",
+			EnvVar: "PLUGIN_TIME_UNIT",
+		},
+		cli.StringFlag{
+			Name:   "suite_hierarchy",
+			Usage:  "Lists above the test cases, e.g. \"files=path > rules=id\" (list=suite name per level).",
+			EnvVar: "PLUGIN_SUITE_HIERARCHY",
+		},
+		cli.StringFlag{
+			Name:   "suite_hierarchy_style",
+			Usage:  "How suite_hierarchy levels are written: dotted (default) suite names or nested suites.",
+			EnvVar: "PLUGIN_SUITE_HIERARCHY_STYLE",
+		},
+		cli.StringFlag{
+			Name:   "test_junit_failure_body",
+			Usage:  "JUnit failure body: json or yaml to dump the test case record, a JSON path or a template.",
+			EnvVar: "PLUGIN_TEST_JUNIT_FAILURE_BODY",
+		},
+		cli.StringFlag{
+			Name:   "test_junit_failure_type",
+			Usage:  "JUnit failure type attribute (JSON path or fixed value), e.g. the rule ID.",
+			EnvVar: "PLUGIN_TEST_JUNIT_FAILURE_TYPE",
+		},
+		cli.StringFlag{
+			Name:   "test_junit_system_out",
+			Usage:  "JUnit suite system-out (JSON path), e.g. the log of the tool.",
+			EnvVar: "PLUGIN_TEST_JUNIT_SYSTEM_OUT",
+		},
+		cli.StringFlag{
+			Name:   "test_junit_system_err",
+			Usage:  "JUnit suite system-err (JSON path).",
+			EnvVar: "PLUGIN_TEST_JUNIT_SYSTEM_ERR",
+		},
+		cli.StringFlag{
+			Name:   "test_junit_list_system_out",
+			Usage:  "JUnit test case system-out (JSON path), e.g. comments[].description.",
+			EnvVar: "PLUGIN_TEST_JUNIT_LIST_SYSTEM_OUT",
+		},
+		cli.StringFlag{
+			Name:   "test_junit_list_system_err",
+			Usage:  "JUnit test case system-err (JSON path).",
+			EnvVar: "PLUGIN_TEST_JUNIT_LIST_SYSTEM_ERR",
+		},
+		cli.StringFlag{
+			Name:   "properties",
+			Usage:  "Static suite properties, e.g. pipeline=<+pipeline.executionId>,commit=<+codebase.commitSha>.",
+			EnvVar: "PLUGIN_PROPERTIES",
+		},
+		cli.StringFlag{
+			Name:   "suite_properties",
+			Usage:  "Suite properties mapped from the JSON, e.g. scanner=tool.name (name=JSON path).",
+			EnvVar: "PLUGIN_SUITE_PROPERTIES",
+		},
+		cli.StringFlag{
+			Name:   "case_properties",
+			Usage:  "Test case properties mapped from the JSON, e.g. severity=level,rule_id=code (name=JSON path).",
+			EnvVar: "PLUGIN_CASE_PROPERTIES",
+		},
+		cli.StringFlag{
+			Name:   "group_by",
+			Usage:  "Comma separated fields partitioning the test cases into one suite per value, e.g. file or file,level.",
+			EnvVar: "PLUGIN_GROUP_BY",
+		},
+		cli.StringFlag{
+			Name:   "suite_time_aggregation",
+			Usage:  "Suite time when test_junit_time is not mapped: sum (default), max or none.",
+			EnvVar: "PLUGIN_SUITE_TIME_AGGREGATION",
+		},
+		cli.StringFlag{
+			Name:   "test_junit_timestamp",
+			Usage:  "JUnit suite timestamp (key, JSONPath or fixed value), defaults to now.",
+			EnvVar: "PLUGIN_TEST_JUNIT_TIMESTAMP",
+		},
+		cli.StringFlag{
+			Name:   "test_junit_hostname",
+			Usage:  "JUnit suite hostname (key, JSONPath or fixed value), defaults to the local hostname.",
+			EnvVar: "PLUGIN_TEST_JUNIT_HOSTNAME",
+		},
 		cli.BoolFlag{
 			Name:   "fail_on_errors",
 			Usage:  "Fail the execution on errors.",
@@ -86,18 +167,186 @@ func main() {
 			Usage:  "JSON list is nested",
 			EnvVar: "PLUGIN_NESTED_JSON_LIST",
 		},
+		cli.StringFlag{
+			Name:   "output_file, output",
+			Usage:  "JUnit report file, defaults to <test_name>-junit.xml, - writes it to stdout.",
+			EnvVar: "PLUGIN_OUTPUT_FILE",
+		},
+		cli.StringFlag{
+			Name:   "log_level",
+			Usage:  "Log level: error, warn, info (default) or debug.",
+			EnvVar: "PLUGIN_LOG_LEVEL",
+		},
+		cli.StringFlag{
+			Name:   "log_format",
+			Usage:  "Log format: text (default) or json.",
+			EnvVar: "PLUGIN_LOG_FORMAT",
+		},
+		cli.StringFlag{
+			Name:   "redact_fields",
+			Usage:  "Comma separated fields whose values are redacted, e.g. secret,match.",
+			EnvVar: "PLUGIN_REDACT_FIELDS",
+		},
+		cli.StringFlag{
+			Name:   "redact_patterns",
+			Usage:  "Regular expressions redacted from messages and the config table, one per line.",
+			EnvVar: "PLUGIN_REDACT_PATTERNS",
+		},
+		cli.IntFlag{
+			Name:   "max_printed_content",
+			Usage:  "Characters of json_content printed in the config table, -1 prints it whole.",
+			Value:  DefaultMaxPrintedContent,
+			EnvVar: "PLUGIN_MAX_PRINTED_CONTENT",
+		},
+		cli.IntFlag{
+			Name:   "max_failures",
+			Usage:  "Quality gate: failures allowed across all suites, -1 for no limit.",
+			Value:  -1,
+			EnvVar: "PLUGIN_MAX_FAILURES",
+		},
+		cli.IntFlag{
+			Name:   "max_errors",
+			Usage:  "Quality gate: errors allowed across all suites, -1 for no limit.",
+			Value:  -1,
+			EnvVar: "PLUGIN_MAX_ERRORS",
+		},
+		cli.Float64Flag{
+			Name:   "min_score",
+			Usage:  "Quality gate: minimum score (0-100), -1 for no limit.",
+			Value:  -1,
+			EnvVar: "PLUGIN_MIN_SCORE",
+		},
+		cli.StringFlag{
+			Name:   "severity_limits",
+			Usage:  "Quality gate: test cases allowed per severity, e.g. error=0,warning=10.",
+			EnvVar: "PLUGIN_SEVERITY_LIMITS",
+		},
+		cli.IntFlag{
+			Name:   "max_suite_failures",
+			Usage:  "Quality gate: failures plus errors allowed in any single suite, -1 for no limit.",
+			Value:  -1,
+			EnvVar: "PLUGIN_MAX_SUITE_FAILURES",
+		},
+		cli.BoolFlag{
+			Name:   "quiet",
+			Usage:  "Do not print the banners, config and status tables.",
+			EnvVar: "PLUGIN_QUIET",
+		},
+		cli.StringFlag{
+			Name:   "output_dir",
+			Usage:  "Directory of the JUnit report files, created when missing.",
+			EnvVar: "PLUGIN_OUTPUT_DIR",
+		},
+		cli.BoolFlag{
+			Name:   "split_suites",
+			Usage:  "Write one JUnit report file per test suite.",
+			EnvVar: "PLUGIN_SPLIT_SUITES",
+		},
 		cli.StringSliceFlag{
 			Name:   "test_junit_skip_field",
-			Usage:  "Skip fields in JUnit XML.",
+			Usage:  "Field that marks a test case as skipped (key or JSONPath).",
 			EnvVar: "PLUGIN_TEST_JUNIT_SKIP_FIELD",
 		},
+		cli.StringFlag{
+			Name:   "test_junit_skip_message",
+			Usage:  "Message of a skipped test case (key, JSONPath or fixed value).",
+			EnvVar: "PLUGIN_TEST_JUNIT_SKIP_MESSAGE",
+		},
+		cli.StringFlag{
+			Name:   "fail_when",
+			Usage:  "Expression failing a test case, e.g. \"grade < 5\" or \"(grade ?? 0) < 5\" for a default when grade is missing (replaces test_junit_list_failure presence).",
+			EnvVar: "PLUGIN_FAIL_WHEN",
+		},
+		cli.StringFlag{
+			Name:   "skip_when",
+			Usage:  "Expression skipping a test case, e.g. \"status == 'ignored'\".",
+			EnvVar: "PLUGIN_SKIP_WHEN",
+		},
+		cli.StringFlag{
+			Name:   "error_when",
+			Usage:  "Expression reporting a test case as error, e.g. \"level == 'error'\".",
+			EnvVar: "PLUGIN_ERROR_WHEN",
+		},
+		cli.StringFlag{
+			Name:   "status_field",
+			Usage:  "Field with the status of a test case, e.g. status or ok (key or JSONPath).",
+			EnvVar: "PLUGIN_STATUS_FIELD",
+		},
+		cli.StringFlag{
+			Name:   "status_map",
+			Usage:  "Status to outcome mapping, e.g. passed=pass,failed=failure,broken=error,pending=skipped.",
+			EnvVar: "PLUGIN_STATUS_MAP",
+		},
+		cli.StringFlag{
+			Name:   "severity_field",
+			Usage:  "Field with the severity of a test case (key or JSONPath).",
+			EnvVar: "PLUGIN_SEVERITY_FIELD",
+		},
+		cli.StringFlag{
+			Name:   "severity_map",
+			Usage:  "Severity to outcome mapping, e.g. error=error,warning=failure,info=skipped,style=pass.",
+			EnvVar: "PLUGIN_SEVERITY_MAP",
+		},
+		cli.StringFlag{
+			Name:   "mapping_engine",
+			Usage:  "How test_junit_* settings are evaluated: jsonpath (default) or jq.",
+			EnvVar: "PLUGIN_MAPPING_ENGINE",
+		},
+		cli.StringFlag{
+			Name:   "mapping_file",
+			Usage:  "YAML/JSON mapping file with the test_junit_* settings.",
+			EnvVar: "PLUGIN_MAPPING_FILE",
+		},
+		cli.StringFlag{
+			Name:   "preset",
+			Usage:  "Bundled mapping for a known tool: hadolint, kube-score or trivy.",
+			EnvVar: "PLUGIN_PRESET",
+		},
+		cli.StringFlag{
+			Name:   "input_format",
+			Usage:  "Format of the input: json (default), ndjson, yaml, toml, csv, tsv or auto.",
+			EnvVar: "PLUGIN_INPUT_FORMAT",
+		},
 	}
 	app.Run(os.Args)
 }
 
 func run(c *cli.Context) {
 	if c.String("json_file_name") != "" && c.String("json_content") != "" {
-		fmt.Println("Error: Please specify either json_file_name or json_content, but not both.")
+		fmt.Fprintln(os.Stderr, "Error: Please specify either json_file_name or json_content, but not both.")
+		os.Exit(1)
+	}
+
+	severityMap, err := ParseOutcomeMap(c.String("severity_map"))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error: severity_map:", err)
+		os.Exit(1)
+	}
+
+	statusMap, err := ParseOutcomeMap(c.String("status_map"))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error: status_map:", err)
+		os.Exit(1)
+	}
+
+	suiteHierarchy, err := ParseSuiteHierarchy(c.String("suite_hierarchy"))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error: suite_hierarchy:", err)
+		os.Exit(1)
+	}
+
+	properties := map[string]map[string]string{}
+	for _, flag := range []string{"properties", "suite_properties", "case_properties"} {
+		properties[flag], err = ParseProperties(c.String(flag))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error: "+flag+":", err)
+			os.Exit(1)
+		}
+	}
+
+	severityLimits, err := ParseSeverityLimits(c.String("severity_limits"))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error: severity_limits:", err)
 		os.Exit(1)
 	}
 
@@ -112,16 +361,68 @@ func run(c *cli.Context) {
 		TestJUnitListClassName: c.String("test_junit_list_class_name"),
 		TestJUnitListFailure:   c.String("test_junit_list_failure"),
 		TestJUnitListTime:      c.String("test_junit_list_time"),
+		TimeUnit:               c.String("time_unit"),
+		SuiteTimeAggregation:   c.String("suite_time_aggregation"),
+		TestJUnitTimestamp:     c.String("test_junit_timestamp"),
+		TestJUnitHostname:      c.String("test_junit_hostname"),
 		JsonFileName:           c.String("json_file_name"),
 		JsonContent:            c.String("json_content"),
 		FailOnFailure:          c.Bool("fail_on_errors"),
 		NestedJsonList:         c.Bool("nested_json_list"),
+		SuiteHierarchy:         suiteHierarchy,
+		SuiteHierarchyStyle:    c.String("suite_hierarchy_style"),
+		GroupBy:                ParseGroupBy(c.String("group_by")),
+		TestJUnitSystemOut:     c.String("test_junit_system_out"),
+		TestJUnitSystemErr:     c.String("test_junit_system_err"),
+		TestJUnitListSystemOut: c.String("test_junit_list_system_out"),
+		TestJUnitListSystemErr: c.String("test_junit_list_system_err"),
+		Properties:             properties["properties"],
+		SuiteProperties:        properties["suite_properties"],
+		CaseProperties:         properties["case_properties"],
+		TestJUnitFailureBody:   c.String("test_junit_failure_body"),
+		TestJUnitFailureType:   c.String("test_junit_failure_type"),
 		TestJUnitSkipField:     c.String("test_junit_skip_field"),
+		TestJUnitSkipMessage:   c.String("test_junit_skip_message"),
+		FailWhen:               c.String("fail_when"),
+		SkipWhen:               c.String("skip_when"),
+		ErrorWhen:              c.String("error_when"),
+		StatusField:            c.String("status_field"),
+		StatusMap:              statusMap,
+		SeverityField:          c.String("severity_field"),
+		SeverityMap:            severityMap,
+		MappingEngine:          c.String("mapping_engine"),
+		MappingFile:            c.String("mapping_file"),
+		Preset:                 c.String("preset"),
+		InputFormat:            c.String("input_format"),
+		OutputFile:             c.String("output_file"),
+		OutputDir:              c.String("output_dir"),
+		SplitSuites:            c.Bool("split_suites"),
+		Quiet:                  c.Bool("quiet"),
+		LogLevel:               c.String("log_level"),
+		LogFormat:              c.String("log_format"),
+		RedactFields:           ParseGroupBy(c.String("redact_fields")),
+		RedactPatterns:         ParseRedactPatterns(c.String("redact_patterns")),
+		MaxPrintedContent:      c.Int("max_printed_content"),
+		MaxFailures:            c.Int("max_failures"),
+		MaxErrors:              c.Int("max_errors"),
+		MinScore:               c.Float64("min_score"),
+		SeverityLimits:         severityLimits,
+		MaxSuiteFailures:       c.Int("max_suite_failures"),
+	}
+
+	config.explicitFlags = map[string]bool{}
+	for _, flag := range []string{"nested_json_list", "fail_on_errors", "split_suites", "max_failures", "max_errors", "min_score", "max_suite_failures", "severity_limits"} {
+		config.explicitFlags[flag] = c.IsSet(flag)
 	}
 
 	plugin := Plugin{Config: config}
 	if err := plugin.Exec(); err != nil {
-		fmt.Println(err)
+		fmt.Fprintln(os.Stderr, err)
+		// a failed quality gate exits with the code of its rule (see gate.go)
+		var gateErr *GateError
+		if errors.As(err, &gateErr) {
+			os.Exit(gateErr.Code)
+		}
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,328 @@
+package main
+
+// A mapping file replaces the flat test_junit_* settings with one reviewable document
+// (YAML or JSON) that can be versioned next to the pipeline:
+//
+//   version: 1
+//   engine: jsonpath
+//   time_unit: ms
+//   suite_time: sum
+//   nested: true
+//   suite:
+//     name: object_name
+//     description: file_name
+//     time: file_row
+//     cases: checks
+//     hierarchy:            # lists above the cases, instead of nested (see hierarchy.go)
+//       - {list: files, name: path}
+//     hierarchy_style: dotted
+//     group_by: [file]      # one suite per value of the fields (see group.go)
+//     properties: {source: tool.name}
+//     system_out: log
+//     system_err: stderr
+//   case:
+//     name: name
+//     classname: comment
+//     time: grade
+//     failure: comments[].summary
+//     failure_body: json    # or yaml, a path or a template
+//     failure_type: check.id
+//     skip: skipped
+//     skip_message: skip_reason
+//     fail_when: grade < 5  # expressions, see expression.go
+//     skip_when: grade == 0
+//     error_when: ""
+//     properties: {rule_id: check.id, severity: grade}
+//     system_out: comments[].description
+//   status:                 # test runner verdicts, instead of failure (see outcome.go)
+//     field: status
+//     map: {passed: pass, failed: failure, broken: error}
+//   severity:
+//     field: level
+//     map: {error: error, warning: failure, info: skipped, style: pass}
+//   output:
+//     name: kube-score
+//     fail_on_errors: true
+//     file: reports/kube-score.xml
+//     dir: reports
+//     split_suites: false
+//     properties: {team: platform}  # static suite properties
+//   gate:                   # quality gate, see gate.go
+//     max_failures: 0
+//     max_errors: 0
+//     min_score: 80
+//     max_suite_failures: 5
+//     severity: {error: 0, warning: 10}
+//
+// Unknown keys are rejected. Settings passed as flags or PLUGIN_* variables take precedence
+// over the values of the mapping file.
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const MappingVersion = 1
+
+type (
+	Mapping struct {
+		Version   int             `yaml:"version"`
+		Engine    string          `yaml:"engine"`
+		TimeUnit  string          `yaml:"time_unit"`
+		SuiteTime string          `yaml:"suite_time"`
+		Nested    bool            `yaml:"nested"`
+		Suite     MappingSuite    `yaml:"suite"`
+		Case      MappingCase     `yaml:"case"`
+		Status    MappingStatus   `yaml:"status"`
+		Severity  MappingSeverity `yaml:"severity"`
+		Output    MappingOutput   `yaml:"output"`
+		Gate      MappingGate     `yaml:"gate"`
+	}
+	MappingSuite struct {
+		Name           string            `yaml:"name"`
+		Description    string            `yaml:"description"`
+		Time           string            `yaml:"time"`
+		Timestamp      string            `yaml:"timestamp"`
+		Hostname       string            `yaml:"hostname"`
+		Cases          string            `yaml:"cases"`
+		Hierarchy      []SuiteLevel      `yaml:"hierarchy"`
+		HierarchyStyle string            `yaml:"hierarchy_style"`
+		GroupBy        []string          `yaml:"group_by"`
+		Properties     map[string]string `yaml:"properties"`
+		SystemOut      string            `yaml:"system_out"`
+		SystemErr      string            `yaml:"system_err"`
+	}
+	MappingCase struct {
+		Name        string            `yaml:"name"`
+		Classname   string            `yaml:"classname"`
+		Time        string            `yaml:"time"`
+		Failure     string            `yaml:"failure"`
+		FailureBody string            `yaml:"failure_body"`
+		FailureType string            `yaml:"failure_type"`
+		Skip        string            `yaml:"skip"`
+		SkipMessage string            `yaml:"skip_message"`
+		FailWhen    string            `yaml:"fail_when"`
+		SkipWhen    string            `yaml:"skip_when"`
+		ErrorWhen   string            `yaml:"error_when"`
+		Properties  map[string]string `yaml:"properties"`
+		SystemOut   string            `yaml:"system_out"`
+		SystemErr   string            `yaml:"system_err"`
+	}
+	// MappingStatus has the keys of MappingSeverity, its own type names the section of unknown keys.
+	MappingStatus struct {
+		Field string            `yaml:"field"`
+		Map   map[string]string `yaml:"map"`
+	}
+	MappingSeverity struct {
+		Field string            `yaml:"field"`
+		Map   map[string]string `yaml:"map"`
+	}
+	MappingGate struct {
+		MaxFailures      *int           `yaml:"max_failures"`
+		MaxErrors        *int           `yaml:"max_errors"`
+		MinScore         *float64       `yaml:"min_score"`
+		MaxSuiteFailures *int           `yaml:"max_suite_failures"`
+		Severity         map[string]int `yaml:"severity"`
+	}
+	MappingOutput struct {
+		Name         string            `yaml:"name"`
+		FailOnErrors bool              `yaml:"fail_on_errors"`
+		File         string            `yaml:"file"`
+		Dir          string            `yaml:"dir"`
+		SplitSuites  bool              `yaml:"split_suites"`
+		Properties   map[string]string `yaml:"properties"`
+	}
+)
+
+// LoadMapping reads and validates a YAML or JSON mapping file.
+func LoadMapping(filename string) (*Mapping, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	mapping, err := ParseMapping(content)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mapping file %s: %s", filename, err)
+	}
+	return mapping, nil
+}
+
+// ParseMapping decodes a mapping document. JSON documents are valid YAML, so both are
+// decoded the same way.
+func ParseMapping(content []byte) (*Mapping, error) {
+	mapping := &Mapping{}
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(mapping); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("mapping document is empty")
+		}
+		var typeErr *yaml.TypeError
+		if errors.As(err, &typeErr) {
+			return nil, fmt.Errorf("%s", mappingErrors(typeErr.Errors))
+		}
+		return nil, err
+	}
+	if err := mapping.Validate(); err != nil {
+		return nil, err
+	}
+	return mapping, nil
+}
+
+// Validate checks the values that the YAML decoder cannot check by itself.
+func (m *Mapping) Validate() error {
+	if m.Version != 0 && m.Version != MappingVersion {
+		return fmt.Errorf("unsupported version %d, expected %d", m.Version, MappingVersion)
+	}
+	switch m.Engine {
+	case "", MappingEngineJSONPath, MappingEngineJq:
+	default:
+		return fmt.Errorf("engine: unknown mapping engine %q, expected %s or %s", m.Engine, MappingEngineJSONPath, MappingEngineJq)
+	}
+	if err := validateTimeUnit(m.TimeUnit); err != nil {
+		return fmt.Errorf("time_unit: %s", err)
+	}
+	if err := validateTimeAggregation(m.SuiteTime); err != nil {
+		return fmt.Errorf("suite_time: %s", err)
+	}
+	if err := validateOutcomeMap(m.Status.Map); err != nil {
+		return fmt.Errorf("status.map: %s", err)
+	}
+	if err := validateOutcomeMap(m.Severity.Map); err != nil {
+		return fmt.Errorf("severity.map: %s", err)
+	}
+	if err := validateSuiteHierarchy(m.Suite.Hierarchy, m.Suite.HierarchyStyle); err != nil {
+		return fmt.Errorf("suite.hierarchy: %s", err)
+	}
+	for severity, limit := range m.Gate.Severity {
+		if limit < 0 {
+			return fmt.Errorf("gate.severity: invalid limit %d for severity %q", limit, severity)
+		}
+	}
+	if m.Suite.Cases == "" {
+		return fmt.Errorf("suite.cases is required")
+	}
+	if m.Case.Name == "" {
+		return fmt.Errorf("case.name is required")
+	}
+	return nil
+}
+
+// Apply copies the mapping into config, keeping every setting already present in config.
+func (m *Mapping) Apply(config *Config) {
+	setDefault(&config.MappingEngine, m.Engine)
+	setDefault(&config.TimeUnit, m.TimeUnit)
+	setDefault(&config.SuiteTimeAggregation, m.SuiteTime)
+	setDefault(&config.TestJUnitName, m.Suite.Name)
+	setDefault(&config.TestDescription, m.Suite.Description)
+	setDefault(&config.TestJUnitTime, m.Suite.Time)
+	setDefault(&config.TestJUnitTimestamp, m.Suite.Timestamp)
+	setDefault(&config.TestJUnitHostname, m.Suite.Hostname)
+	setDefault(&config.TestJUnitList, m.Suite.Cases)
+	if len(config.SuiteHierarchy) == 0 {
+		config.SuiteHierarchy = m.Suite.Hierarchy
+	}
+	setDefault(&config.SuiteHierarchyStyle, m.Suite.HierarchyStyle)
+	if len(config.GroupBy) == 0 {
+		config.GroupBy = m.Suite.GroupBy
+	}
+	setDefault(&config.TestJUnitListName, m.Case.Name)
+	setDefault(&config.TestJUnitListClassName, m.Case.Classname)
+	setDefault(&config.TestJUnitListTime, m.Case.Time)
+	setDefault(&config.TestJUnitListFailure, m.Case.Failure)
+	setDefault(&config.TestJUnitFailureBody, m.Case.FailureBody)
+	setDefault(&config.TestJUnitFailureType, m.Case.FailureType)
+	setDefault(&config.TestJUnitSkipField, m.Case.Skip)
+	setDefault(&config.TestJUnitSkipMessage, m.Case.SkipMessage)
+	setDefault(&config.TestJUnitSystemOut, m.Suite.SystemOut)
+	setDefault(&config.TestJUnitSystemErr, m.Suite.SystemErr)
+	setDefault(&config.TestJUnitListSystemOut, m.Case.SystemOut)
+	setDefault(&config.TestJUnitListSystemErr, m.Case.SystemErr)
+	setDefault(&config.FailWhen, m.Case.FailWhen)
+	setDefault(&config.SkipWhen, m.Case.SkipWhen)
+	setDefault(&config.ErrorWhen, m.Case.ErrorWhen)
+	setDefault(&config.StatusField, m.Status.Field)
+	if len(config.StatusMap) == 0 {
+		config.StatusMap = m.Status.Map
+	}
+	setDefault(&config.SeverityField, m.Severity.Field)
+	if len(config.SeverityMap) == 0 {
+		config.SeverityMap = m.Severity.Map
+	}
+	setDefault(&config.TestName, m.Output.Name)
+	if len(config.Properties) == 0 {
+		config.Properties = m.Output.Properties
+	}
+	if len(config.SuiteProperties) == 0 {
+		config.SuiteProperties = m.Suite.Properties
+	}
+	if len(config.CaseProperties) == 0 {
+		config.CaseProperties = m.Case.Properties
+	}
+	setDefaultBool(&config.NestedJsonList, m.Nested, config.explicitFlags["nested_json_list"])
+	setDefaultBool(&config.FailOnFailure, m.Output.FailOnErrors, config.explicitFlags["fail_on_errors"])
+	setDefault(&config.OutputFile, m.Output.File)
+	setDefault(&config.OutputDir, m.Output.Dir)
+	setDefaultBool(&config.SplitSuites, m.Output.SplitSuites, config.explicitFlags["split_suites"])
+	setDefaultLimit(&config.MaxFailures, m.Gate.MaxFailures, config.explicitFlags["max_failures"])
+	setDefaultLimit(&config.MaxErrors, m.Gate.MaxErrors, config.explicitFlags["max_errors"])
+	setDefaultLimit(&config.MaxSuiteFailures, m.Gate.MaxSuiteFailures, config.explicitFlags["max_suite_failures"])
+	if config.MinScore < 0 && m.Gate.MinScore != nil && !config.explicitFlags["min_score"] {
+		config.MinScore = *m.Gate.MinScore
+	}
+	if len(config.SeverityLimits) == 0 && len(m.Gate.Severity) > 0 && !config.explicitFlags["severity_limits"] {
+		config.SeverityLimits = map[string]int{}
+		for severity, limit := range m.Gate.Severity {
+			config.SeverityLimits[strings.ToLower(severity)] = limit
+		}
+	}
+}
+
+var unknownFieldRegex = regexp.MustCompile(`field (\S+) not found in type main\.(?:Mapping|Suite)(\w*)`)
+
+// mappingErrors rewrites the decoder errors with the mapping section names,
+// e.g. "line 3: unknown key nmae in suite".
+func mappingErrors(messages []string) string {
+	for i, message := range messages {
+		messages[i] = unknownFieldRegex.ReplaceAllStringFunc(message, func(match string) string {
+			groups := unknownFieldRegex.FindStringSubmatch(match)
+			section := strings.ToLower(groups[2])
+			switch section {
+			case "":
+				section = "mapping"
+			case "level":
+				section = "suite.hierarchy"
+			}
+			return "unknown key " + groups[1] + " in " + section
+		})
+	}
+	return strings.Join(messages, "; ")
+}
+
+func setDefault(setting *string, value string) {
+	if *setting == "" {
+		*setting = value
+	}
+}
+
+// setDefaultBool enables a boolean setting unless its flag was given explicitly, e.g. false.
+func setDefaultBool(setting *bool, value bool, explicit bool) {
+	if !explicit {
+		*setting = *setting || value
+	}
+}
+
+// setDefaultLimit sets a disabled (negative) quality gate limit unless its flag was given
+// explicitly, e.g. -1 to disable the limit of the mapping file.
+func setDefaultLimit(setting *int, value *int, explicit bool) {
+	if *setting < 0 && value != nil && !explicit {
+		*setting = *value
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+// NDJSON (JSON Lines) input, as emitted by `go test -json`, gitleaks, semgrep and others:
+// one JSON value per line. Each line is treated like an element of a top level JSON list,
+// so it is a test case, or a test suite when NestedJsonList is true. The input is read line
+// by line and every record is dropped as soon as it is converted, so large logs never have
+// to be held in memory.
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ParseJunitNDJSON converts an NDJSON stream to JUnit.
+func ParseJunitNDJSON(reader io.Reader, settings Config) (*Testsuites, error) {
+	if err := prepareMapping(settings); err != nil {
+		return nil, err
+	}
+
+	testSuites := &Testsuites{}
+	var testSuite Testsuite
+	if !settings.NestedJsonList {
+		// Suite level settings can only be fixed values, there is no document to resolve them
+		testSuite = newTestSuite(nil, settings)
+	}
+
+	buffered := bufio.NewReader(reader)
+	lineNumber := 0
+	for {
+		line, readErr := buffered.ReadBytes('\n')
+		if readErr != nil && !errors.Is(readErr, io.EOF) {
+			return nil, readErr
+		}
+		lineNumber++
+
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 {
+			var record interface{}
+			if err := json.Unmarshal(line, &record); err != nil {
+				return nil, fmt.Errorf("failed to parse NDJSON line %d: %s", lineNumber, err)
+			}
+			record = redactFields(record, settings.RedactFields)
+			if settings.NestedJsonList {
+				nestedSuite, err := parseTestSuite(record, settings)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %s", lineNumber, err)
+				}
+				testSuites.TestSuite = append(testSuites.TestSuite, nestedSuite)
+			} else if err := testSuite.addTestCase(record, settings); err != nil {
+				return nil, fmt.Errorf("line %d: %s", lineNumber, err)
+			}
+		}
+
+		if readErr != nil {
+			break
+		}
+	}
+
+	if !settings.NestedJsonList {
+		testSuites.TestSuite = append(testSuites.TestSuite, testSuite)
+	}
+	testSuites.TestSuite = groupTestSuites(testSuites.TestSuite, settings)
+	status = newStatus(testSuites)
+
+	return testSuites, nil
+}
@@ -0,0 +1,168 @@
+package main
+
+// Outcomes of a test case. A severity (or status) field can decide the outcome of each
+// record through a value mapping such as "error=error,warning=failure,info=skipped,style=pass",
+// instead of the presence of test_junit_list_failure alone.
+//
+// A status field holds the verdict of a test runner ("status": "passed", "ok": true). Without
+// a StatusMap the usual values are understood, see DefaultStatusMap.
+//
+// The <failure> and <error> elements get a body from TestJUnitFailureBody (a path, a template,
+// or "json"/"yaml" to dump the whole record) and a type attribute from TestJUnitFailureType.
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	FailureBodyJSON = "json"
+	FailureBodyYAML = "yaml"
+)
+
+const (
+	OutcomePass    = "pass"
+	OutcomeFailure = "failure"
+	OutcomeError   = "error"
+	OutcomeSkipped = "skipped"
+)
+
+// DefaultStatusMap maps the common test runner statuses, values are matched ignoring case.
+var DefaultStatusMap = map[string]string{
+	"pass":     OutcomePass,
+	"passed":   OutcomePass,
+	"ok":       OutcomePass,
+	"success":  OutcomePass,
+	"true":     OutcomePass,
+	"fail":     OutcomeFailure,
+	"failed":   OutcomeFailure,
+	"failure":  OutcomeFailure,
+	"false":    OutcomeFailure,
+	"error":    OutcomeError,
+	"errored":  OutcomeError,
+	"broken":   OutcomeError,
+	"skip":     OutcomeSkipped,
+	"skipped":  OutcomeSkipped,
+	"pending":  OutcomeSkipped,
+	"ignored":  OutcomeSkipped,
+	"disabled": OutcomeSkipped,
+}
+
+// statusMap returns the StatusMap setting, DefaultStatusMap when empty.
+func statusMap(settings Config) map[string]string {
+	if len(settings.StatusMap) == 0 {
+		return DefaultStatusMap
+	}
+	return settings.StatusMap
+}
+
+// ParseOutcomeMap parses a "value=outcome,value=outcome" list. ":" is accepted as separator too.
+func ParseOutcomeMap(value string) (map[string]string, error) {
+	outcomes := map[string]string{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			parts = strings.SplitN(entry, ":", 2)
+		}
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid outcome mapping %q, expected value=outcome", entry)
+		}
+		outcomes[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	if err := validateOutcomeMap(outcomes); err != nil {
+		return nil, err
+	}
+	return outcomes, nil
+}
+
+// validateOutcomeMap checks that every value maps to a known outcome.
+func validateOutcomeMap(outcomes map[string]string) error {
+	for value, outcome := range outcomes {
+		switch strings.ToLower(outcome) {
+		case OutcomePass, OutcomeFailure, OutcomeError, OutcomeSkipped:
+		default:
+			return fmt.Errorf("invalid outcome %q for %q, expected pass, failure, error or skipped", outcome, value)
+		}
+	}
+	return nil
+}
+
+// formatOutcomeMap renders an outcome mapping back to the "value=outcome" form.
+func formatOutcomeMap(outcomes map[string]string) string {
+	entries := []string{}
+	for value, outcome := range outcomes {
+		entries = append(entries, value+"="+outcome)
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, ",")
+}
+
+// lookupOutcome returns the outcome mapped to value, ignoring case.
+func lookupOutcome(outcomes map[string]string, value string) (string, bool) {
+	for key, outcome := range outcomes {
+		if strings.EqualFold(key, value) {
+			return strings.ToLower(outcome), true
+		}
+	}
+	return "", false
+}
+
+// setOutcome sets the <failure>, <error> or <skipped> element of a test case.
+func setOutcome(testCase *Testcase, outcome string, message string) {
+	switch outcome {
+	case OutcomeFailure:
+		testCase.Failure = &Failure{Message: message}
+	case OutcomeError:
+		testCase.Error = &Failure{Message: message}
+	case OutcomeSkipped:
+		testCase.Skipped = &Skipped{Message: message}
+	}
+}
+
+// setFailureDetails fills the body and type of the <failure> or <error> element of a test case.
+func setFailureDetails(testCase *Testcase, record interface{}, settings Config) {
+	failure := testCase.Failure
+	if failure == nil {
+		failure = testCase.Error
+	}
+	if failure == nil {
+		return
+	}
+	failure.Text = failureBody(record, settings.TestJUnitFailureBody)
+	// the type is left out when the record has no such field
+	failure.Type, _ = resolveString(record, settings.TestJUnitFailureType)
+}
+
+// failureBody renders the failure body of a record: a dump of the whole record or the
+// value of a path or template.
+func failureBody(record interface{}, setting string) string {
+	switch setting {
+	case "":
+		return ""
+	case FailureBodyJSON:
+		var content strings.Builder
+		encoder := json.NewEncoder(&content)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(record); err != nil {
+			return stringify(record)
+		}
+		return content.String()
+	case FailureBodyYAML:
+		content, err := yaml.Marshal(record)
+		if err != nil {
+			return stringify(record)
+		}
+		return string(content)
+	}
+	body, _ := resolveString(record, setting)
+	return body
+}
@@ -0,0 +1,142 @@
+package main
+
+// The JUnit report is written to "<test_name>-junit.xml" in the current directory unless
+// OutputFile names the file. OutputDir is the directory of relative output files and is
+// created when missing. With SplitSuites every test suite is written to its own file,
+// "<test_name>-<suite name>-junit.xml" (or "<output file name>-<suite name>.xml"), so a
+// Harness reports.paths glob like "junit/*.xml" picks up each one. OutputFile "-" writes
+// the report to stdout, and everything else the plugin prints to stderr.
+//
+// Names taken from the JSON are sanitized: every run of characters other than letters,
+// digits, "." and "_" becomes "-" ("hadolint - build/Dockerfile" -> "hadolint-build-Dockerfile"),
+// and suites ending up with the same file name are numbered.
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const StdoutFileName = "-"
+
+const maxFileNameLength = 100
+
+var unsafeFileNameRegex = regexp.MustCompile(`[^A-Za-z0-9._]+`)
+
+// sanitizeFileName turns a test or suite name into a safe file name component.
+func sanitizeFileName(name string) string {
+	name = unsafeFileNameRegex.ReplaceAllString(name, "-")
+	name = strings.Trim(name, "._-")
+	if len(name) > maxFileNameLength {
+		name = strings.TrimRight(name[:maxFileNameLength], "._-")
+	}
+	return name
+}
+
+// outputFileName returns the path of the JUnit report.
+func outputFileName(settings Config) string {
+	name := settings.OutputFile
+	if name == "" {
+		name = "junit.xml"
+		if prefix := sanitizeFileName(settings.TestName); prefix != "" {
+			name = prefix + "-junit.xml"
+		}
+	}
+	if settings.OutputDir != "" && !filepath.IsAbs(name) {
+		name = filepath.Join(settings.OutputDir, name)
+	}
+	return name
+}
+
+// suiteFileNames returns one output path per test suite for SplitSuites.
+func suiteFileNames(testSuites []Testsuite, settings Config) []string {
+	base := outputFileName(settings)
+	extension := filepath.Ext(base)
+	prefix := strings.TrimSuffix(base, extension)
+	if settings.OutputFile == "" {
+		// "<test_name>-junit.xml" becomes "<test_name>-<suite name>-junit.xml"
+		prefix = strings.TrimSuffix(prefix, "junit")
+		prefix = strings.TrimSuffix(prefix, "-")
+		extension = "-junit" + extension
+	}
+	separator := "-"
+	if strings.HasSuffix(prefix, string(filepath.Separator)) || prefix == "" {
+		separator = ""
+	}
+
+	used := map[string]bool{}
+	names := make([]string, len(testSuites))
+	for i, testSuite := range testSuites {
+		suiteName := sanitizeFileName(testSuite.Name)
+		if suiteName == "" {
+			suiteName = "suite-" + strconv.Itoa(i+1)
+		}
+		name := prefix + separator + suiteName + extension
+		for n := 2; used[name]; n++ {
+			name = prefix + separator + suiteName + "-" + strconv.Itoa(n) + extension
+		}
+		used[name] = true
+		names[i] = name
+	}
+	return names
+}
+
+// writeReport writes the JUnit report, or one report per suite with SplitSuites, and returns
+// the written files.
+func writeReport(junitReport *Testsuites, settings Config) ([]string, error) {
+	if settings.OutputFile == StdoutFileName {
+		if settings.SplitSuites {
+			return nil, fmt.Errorf("split_suites cannot write to stdout")
+		}
+		junitXML, err := marshalReport(junitReport)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := os.Stdout.Write(junitXML); err != nil {
+			return nil, fmt.Errorf("error writing JUnit XML to stdout: %s", err)
+		}
+		return []string{"stdout"}, nil
+	}
+	if !settings.SplitSuites {
+		name := outputFileName(settings)
+		return []string{name}, writeJUnitFile(name, junitReport)
+	}
+
+	names := suiteFileNames(junitReport.TestSuite, settings)
+	for i, testSuite := range junitReport.TestSuite {
+		if err := writeJUnitFile(names[i], &Testsuites{TestSuite: []Testsuite{testSuite}}); err != nil {
+			return names[:i], err
+		}
+	}
+	return names, nil
+}
+
+// marshalReport renders a report as an XML document, the same on stdout and in files.
+func marshalReport(report *Testsuites) ([]byte, error) {
+	junitXML, err := xml.MarshalIndent(report, " ", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling JUnit to XML: %s", err)
+	}
+	return []byte(xml.Header + string(junitXML) + "\n"), nil
+}
+
+// writeJUnitFile marshals a report to name, creating its directory when missing.
+func writeJUnitFile(name string, report *Testsuites) error {
+	junitXML, err := marshalReport(report)
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(name); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("error creating output directory: %s", err)
+		}
+	}
+	if err := os.WriteFile(name, junitXML, 0644); err != nil {
+		return fmt.Errorf("error writing JUnit XML to file: %s", err)
+	}
+	return nil
+}
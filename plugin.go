@@ -29,15 +29,68 @@ package main
 // JsonContent: the content of the JSON file.
 // FailOnFailure: whether to fail on failure.
 // NestedJsonList: whether the JSON list is nested.
+// SuiteHierarchy: the lists above the test cases, to any depth (see hierarchy.go).
+// SuiteHierarchyStyle: dotted (default) suite names or nested test suites.
+// TestJUnitSystemOut, TestJUnitSystemErr: the system-out and system-err of the test suite.
+// TestJUnitListSystemOut, TestJUnitListSystemErr: the system-out and system-err of a test case.
+// Properties: static suite properties, e.g. the pipeline ID or commit SHA (see properties.go).
+// SuiteProperties: suite properties mapped from the suite record.
+// CaseProperties: test case properties mapped from each test case record.
+// GroupBy: fields partitioning the test cases into one suite per value (see group.go).
+// TestJUnitFailureBody: the body of a failure, a path, a template, or json/yaml to dump the record.
+// TestJUnitFailureType: the type attribute of a failure, e.g. the rule ID.
 // TestJUnitSkipField: the field to skip in the JUnit report.
+// TestJUnitSkipMessage: the message of a skipped test case.
+// TimeUnit: the unit of numeric times (ns, us, ms, s, m, h), see duration.go.
+// SuiteTimeAggregation: suite time when TestJUnitTime is not mapped, sum (default), max or none.
+// TestJUnitTimestamp: the timestamp of the test suite, defaults to the conversion time.
+// TestJUnitHostname: the hostname of the test suite, defaults to the local hostname.
+// FailWhen, SkipWhen, ErrorWhen: expressions deciding the outcome of a test case (see expression.go).
+// StatusField: the field holding the status of a test case, e.g. passed, failed, skipped.
+// StatusMap: status value to outcome, defaults to the common statuses (see outcome.go).
+// SeverityField: the field holding the severity of a test case.
+// SeverityMap: severity value to outcome (pass, failure, error, skipped), see outcome.go.
+// MappingEngine: how mapping settings are evaluated, jsonpath (default) or jq.
+// MappingFile: a YAML/JSON mapping document providing the settings above (see mapping.go).
+// Preset: a bundled mapping for a known tool, e.g. hadolint, kube-score or trivy (see preset.go).
+// OutputFile: the JUnit report file, defaults to <TestName>-junit.xml (see output.go).
+// OutputDir: the directory of the JUnit report files.
+// SplitSuites: whether every test suite is written to its own file.
+// Quiet: whether the banners, config and status tables are suppressed.
+// LogLevel: error, warn, info (default) or debug (see logging.go).
+// LogFormat: text (default) or json.
+// RedactFields: fields whose values are redacted from the input (see redact.go).
+// RedactPatterns: regular expressions redacted next to the known secret patterns.
+// MaxPrintedContent: the length of the JsonContent printed in the config table.
+// MaxFailures, MaxErrors: the failures and errors allowed by the quality gate (see gate.go).
+// MinScore: the minimum score of the quality gate.
+// SeverityLimits: the test cases allowed per severity, e.g. error=0,warning=10.
+// MaxSuiteFailures: the failures plus errors allowed in any single test suite.
+// InputFormat: json (default), ndjson (see ndjson.go), yaml, toml, csv, tsv (see csv.go) or
+// auto (see format.go).
+//
+// Every mapping setting accepts a plain key, the legacy "list[].field" syntax or a
+// JSONPath expression such as "$.report.suites[*].cases" (see jsonpath.go), or a jq
+// program when MappingEngine is jq (see jq.go).
 //
 //
 // The JUnit XML format is:
 // <testsuites>
-//   <testsuite name="..." package="..." time="..." tests="..." errors="...">
+//   <testsuite name="..." package="..." time="..." timestamp="..." hostname="..." tests="..." failures="..." errors="..." skipped="...">
+//     <properties><property name="..." value="..."/></properties>
+//     <testcase name="..." classname="...">
+//       <failure message="..." type="...">...</failure>
+//       <system-out>...</system-out>
+//     </testcase>
 //     <testcase name="..." classname="...">
-//       <failure message="..."></failure>
+//       <error message="..."></error>
 //     </testcase>
+//     <testcase name="..." classname="...">
+//       <skipped message="..."></skipped>
+//     </testcase>
+//     <testsuite name="...">...</testsuite> (nested suite hierarchy only)
+//     <system-out>...</system-out>
+//     <system-err>...</system-err>
 //   </testsuite>
 // </testsuites>
 //
@@ -47,10 +100,11 @@ package main
 // $ ./plugin --json_file_name=sample.json --test_name=test --test_description="test description"
 
 import (
-	"encoding/json"
 	"encoding/xml"
 	"fmt"
+	"io"
 	"log"
+	"math"
 	"os"
 	"strconv"
 	"strings"
@@ -72,8 +126,52 @@ type (
 		JsonContent            string
 		FailOnFailure          bool
 		NestedJsonList         bool
+		SuiteHierarchy         []SuiteLevel
+		SuiteHierarchyStyle    string
+		GroupBy                []string
+		TestJUnitSystemOut     string
+		TestJUnitSystemErr     string
+		TestJUnitListSystemOut string
+		TestJUnitListSystemErr string
+		Properties             map[string]string
+		SuiteProperties        map[string]string
+		CaseProperties         map[string]string
+		TestJUnitFailureBody   string
+		TestJUnitFailureType   string
 		TestJUnitSkipField     string
+		TestJUnitSkipMessage   string
+		TimeUnit               string
+		SuiteTimeAggregation   string
+		TestJUnitTimestamp     string
+		TestJUnitHostname      string
+		FailWhen               string
+		SkipWhen               string
+		ErrorWhen              string
+		StatusField            string
+		StatusMap              map[string]string
+		SeverityField          string
+		SeverityMap            map[string]string
+		MappingEngine          string
+		MappingFile            string
+		Preset                 string
+		InputFormat            string
+		OutputFile             string
+		OutputDir              string
+		SplitSuites            bool
+		Quiet                  bool
+		LogLevel               string
+		LogFormat              string
+		RedactFields           []string
+		RedactPatterns         []string
+		MaxPrintedContent      int
+		MaxFailures            int
+		MaxErrors              int
+		MinScore               float64
+		SeverityLimits         map[string]int
+		MaxSuiteFailures       int
 		Status                 Status
+
+		explicitFlags map[string]bool // boolean flags given explicitly, kept over the mapping file
 	}
 	Output struct {
 		OutputFile string // File where plugin output are saved
@@ -84,24 +182,56 @@ type (
 		TestSuite []Testsuite `xml:"testsuite"`
 	}
 	Testsuite struct {
-		Text     string     `xml:",chardata"`
-		Package  string     `xml:"package,attr"`
-		Time     int        `xml:"time,attr"`
-		Tests    int        `xml:"tests,attr"`
-		Errors   int        `xml:"errors,attr"`
-		Name     string     `xml:"name,attr"`
-		TestCase []Testcase `xml:"testcase"`
+		Text       string      `xml:",chardata"`
+		Package    string      `xml:"package,attr"`
+		Time       float64     `xml:"time,attr"`
+		Timestamp  string      `xml:"timestamp,attr,omitempty"`
+		Hostname   string      `xml:"hostname,attr,omitempty"`
+		Tests      int         `xml:"tests,attr"`
+		Failures   int         `xml:"failures,attr"`
+		Errors     int         `xml:"errors,attr"`
+		Skipped    int         `xml:"skipped,attr"`
+		Name       string      `xml:"name,attr"`
+		Properties *Properties `xml:"properties"`
+		TestSuite  []Testsuite `xml:"testsuite"`
+		TestCase   []Testcase  `xml:"testcase"`
+		SystemOut  *SystemLog  `xml:"system-out"`
+		SystemErr  *SystemLog  `xml:"system-err"`
+
+		timeAggregation string // sum or max of the test case (or child suite) times, "" when mapped
 	}
 	Testcase struct {
-		Text      string   `xml:",chardata"`
-		Time      int      `xml:"time,attr"`      // Actual Value Sonar
-		Name      string   `xml:"name,attr"`      // Metric Key
-		Classname string   `xml:"classname,attr"` // The metric Rule
-		Failure   *Failure `xml:"failure"`        // Sonar Failure - show results
+		Text       string      `xml:",chardata"`
+		Time       float64     `xml:"time,attr"`      // Actual Value Sonar
+		Name       string      `xml:"name,attr"`      // Metric Key
+		Classname  string      `xml:"classname,attr"` // The metric Rule
+		Failure    *Failure    `xml:"failure"`        // Sonar Failure - show results
+		Error      *Failure    `xml:"error"`
+		Skipped    *Skipped    `xml:"skipped"`
+		Properties *Properties `xml:"properties"`
+		SystemOut  *SystemLog  `xml:"system-out"`
+		SystemErr  *SystemLog  `xml:"system-err"`
+
+		group    string // values of the GroupBy fields, see group.go
+		severity string // value of the SeverityField, see gate.go
 	}
 	Failure struct {
-		Text    string `xml:",chardata"`
+		Text    string `xml:",cdata"` // keeps the line breaks of multi-line bodies readable
 		Message string `xml:"message,attr"`
+		Type    string `xml:"type,attr,omitempty"`
+	}
+	Skipped struct {
+		Message string `xml:"message,attr"`
+	}
+	SystemLog struct {
+		Text string `xml:",cdata"`
+	}
+	Properties struct {
+		Property []Property `xml:"property"`
+	}
+	Property struct {
+		Name  string `xml:"name,attr"`
+		Value string `xml:"value,attr"`
 	}
 )
 
@@ -110,38 +240,45 @@ type Plugin struct {
 }
 
 type Status struct {
-	Total  int
-	Passed int
-	Errors int
-	Score  float64
+	Total    int
+	Passed   int
+	Failures int
+	Errors   int
+	Skipped  int
+	Score    float64
 }
 
 var status Status
 
+// console receives everything the plugin prints besides the JUnit report itself.
+var console io.Writer = os.Stdout
+
 func printHeader() {
-	fmt.Println("|----------------------------------|")
-	fmt.Println("|  Harness JUnit Converter Plugin  |")
-	fmt.Println("|----------------------------------|")
-	fmt.Println("|     Developer: Diego Pereira     |")
-	fmt.Println("|----------------------------------|")
-	fmt.Println("|     Version: 1.0.0               |")
-	fmt.Println("|----------------------------------|")
-	fmt.Println("|     Date: 2021-09-01             |")
-	fmt.Println("|----------------------------------|")
-	fmt.Println("")
-	fmt.Println("")
+	fmt.Fprintln(console, "|----------------------------------|")
+	fmt.Fprintln(console, "|  Harness JUnit Converter Plugin  |")
+	fmt.Fprintln(console, "|----------------------------------|")
+	fmt.Fprintln(console, "|     Developer: Diego Pereira     |")
+	fmt.Fprintln(console, "|----------------------------------|")
+	fmt.Fprintln(console, "|     Version: 1.0.0               |")
+	fmt.Fprintln(console, "|----------------------------------|")
+	fmt.Fprintln(console, "|     Date: 2021-09-01             |")
+	fmt.Fprintln(console, "|----------------------------------|")
+	fmt.Fprintln(console, "")
+	fmt.Fprintln(console, "")
 }
 
 func printStatusTable(status Status) {
-	fmt.Println("|----------------------------------|")
-	fmt.Println("|             Status               |")
-	fmt.Println("|----------------------------------|")
-	fmt.Printf("  Total:   %-3d                    \n", status.Total)
-	fmt.Printf("  Passed:  %-3d                    \n", status.Passed)
-	fmt.Printf("  Errors:  %-3d                    \n", status.Errors)
-	fmt.Println("|----------------------------------|")
-	fmt.Printf("  Score:   %-7.2f                 \n", status.Score)
-	fmt.Println("|----------------------------------|")
+	fmt.Fprintln(console, "|----------------------------------|")
+	fmt.Fprintln(console, "|             Status               |")
+	fmt.Fprintln(console, "|----------------------------------|")
+	fmt.Fprintf(console, "  Total:   %-3d                    \n", status.Total)
+	fmt.Fprintf(console, "  Passed:  %-3d                    \n", status.Passed)
+	fmt.Fprintf(console, "  Failures:%-3d                    \n", status.Failures)
+	fmt.Fprintf(console, "  Errors:  %-3d                    \n", status.Errors)
+	fmt.Fprintf(console, "  Skipped: %-3d                    \n", status.Skipped)
+	fmt.Fprintln(console, "|----------------------------------|")
+	fmt.Fprintf(console, "  Score:   %-7.2f                 \n", status.Score)
+	fmt.Fprintln(console, "|----------------------------------|")
 }
 
 func exportMetricsToFile(status Status) {
@@ -153,46 +290,93 @@ func exportMetricsToFile(status Status) {
 
 	fmt.Fprintf(file, "TOTAL=%d\n", status.Total)
 	fmt.Fprintf(file, "PASSED=%d\n", status.Passed)
+	fmt.Fprintf(file, "FAILURES=%d\n", status.Failures)
 	fmt.Fprintf(file, "ERRORS=%d\n", status.Errors)
+	fmt.Fprintf(file, "SKIPPED=%d\n", status.Skipped)
 	fmt.Fprintf(file, "SCORE=%.2f\n", status.Score)
 }
 
 func (p *Plugin) Exec() error {
-	printHeader()
+	// With the report on stdout everything else is printed to stderr, or nothing when quiet
+	switch {
+	case p.Config.Quiet:
+		console = io.Discard
+	case p.Config.OutputFile == StdoutFileName:
+		console = os.Stderr
+	}
+	if err := setupLogging(p.Config.LogLevel, p.Config.LogFormat, console); err != nil {
+		return err
+	}
+	if err := setRedactPatterns(p.Config.RedactPatterns); err != nil {
+		return err
+	}
+	if printTables {
+		printHeader()
+	}
+
+	// Load the mapping file, flags keep precedence over its values
+	if p.Config.MappingFile != "" {
+		mapping, err := LoadMapping(p.Config.MappingFile)
+		if err != nil {
+			return err
+		}
+		mapping.Apply(&p.Config)
+	}
+
+	// Bundled presets fill whatever is still missing
+	if p.Config.Preset != "" {
+		mapping, err := LoadPreset(p.Config.Preset)
+		if err != nil {
+			return err
+		}
+		mapping.Apply(&p.Config)
+	}
+
+	if err := validateGate(p.Config); err != nil {
+		return err
+	}
 
 	// Read JSON, Convert to JUnit, and Export XML
-	if p.Config.JsonFileName != "" {
-		// Read the JSON file
-	} else if p.Config.JsonContent != "" {
-		// Use the direct JSON content
-	} else {
+	if p.Config.JsonFileName == "" && p.Config.JsonContent == "" {
 		return fmt.Errorf("either JsonFileName or JsonContent must be specified")
 	}
 
-	jsonContent := ""
+	junitReport := &Testsuites{}
 
-	// code that will convert the JSON to JUnit
-	if p.Config.JsonFileName != "" {
-		// Read the JSON file
-		jsonRead, err := ReadJSON(p.Config.JsonFileName)
-		if err != nil {
-			return fmt.Errorf("error reading JSON file: %s", err)
-		}
-		jsonContent = jsonRead
+	if err := validateInputFormat(p.Config.InputFormat); err != nil {
+		return err
+	}
 
-	} else if p.Config.JsonContent != "" {
+	if p.Config.JsonContent != "" {
 		// Use the direct JSON content
-		jsonContent = p.Config.JsonContent
-	} else {
-		return fmt.Errorf("either JsonFileName or JsonContent must be specified")
+		logger.Info("Parsing JSON to JUnit", "input", "json_content")
+		testSuites, err := p.convert("")
+		if err != nil {
+			return fmt.Errorf("error parsing JSON to JUnit: %s", err)
+		}
+		junitReport.TestSuite = append(junitReport.TestSuite, testSuites.TestSuite...)
 	}
 
-	// Parse JSON to JUnit
-	fmt.Println("Parsing JSON to JUnit...")
-	junitReport, err := ParseJunit(jsonContent, p.Config)
-	if err != nil {
-		return fmt.Errorf("error parsing JSON to JUnit: %s", err)
+	if p.Config.JsonFileName != "" {
+		// Read every JSON file matched by the file name, "-" reads stdin
+		files, err := ExpandInputs(p.Config.JsonFileName)
+		if err != nil {
+			return fmt.Errorf("error reading JSON file: %s", err)
+		}
+		for _, file := range files {
+			logger.Info("Parsing JSON to JUnit", "file", file)
+			testSuites, err := p.convert(file)
+			if err != nil {
+				return fmt.Errorf("error parsing JSON to JUnit: %s: %s", file, err)
+			}
+			if len(files) > 1 {
+				labelTestSuites(testSuites, file, p.Config)
+			}
+			junitReport.TestSuite = append(junitReport.TestSuite, testSuites.TestSuite...)
+		}
 	}
+	redactReport(junitReport.TestSuite)
+	status = newStatus(junitReport)
 
 	// Serialize JUnit to XML and print (or write to file)
 	junitXML, err := xml.MarshalIndent(junitReport, " ", "  ")
@@ -200,10 +384,13 @@ func (p *Plugin) Exec() error {
 		return fmt.Errorf("error marshaling JUnit to XML: %s", err)
 	}
 
-	//save to a file called <test_name_variable>-junit.xml
-	err = os.WriteFile(p.Config.TestName+"-junit.xml", junitXML, 0644)
+	// save to <test_name>-junit.xml, the output file or one file per suite (see output.go)
+	files, err := writeReport(junitReport, p.Config)
 	if err != nil {
-		return fmt.Errorf("error writing JUnit XML to file: %s", err)
+		return err
+	}
+	for _, file := range files {
+		logger.Info("JUnit report written", "file", file)
 	}
 
 	// Print the plugin config parsed
@@ -219,41 +406,83 @@ func (p *Plugin) Exec() error {
 	configs = append(configs, "TestJUnitListClassName: "+p.Config.TestJUnitListClassName)
 	configs = append(configs, "TestJUnitListFailure: "+p.Config.TestJUnitListFailure)
 	configs = append(configs, "TestJUnitListTime: "+p.Config.TestJUnitListTime)
+	configs = append(configs, "TestJUnitFailureBody: "+p.Config.TestJUnitFailureBody)
+	configs = append(configs, "TestJUnitFailureType: "+p.Config.TestJUnitFailureType)
+	configs = append(configs, "TimeUnit: "+p.Config.TimeUnit)
+	configs = append(configs, "SuiteTimeAggregation: "+p.Config.SuiteTimeAggregation)
+	configs = append(configs, "TestJUnitTimestamp: "+p.Config.TestJUnitTimestamp)
+	configs = append(configs, "TestJUnitHostname: "+p.Config.TestJUnitHostname)
 	configs = append(configs, "JsonFileName: "+p.Config.JsonFileName)
-	configs = append(configs, "JsonContent: "+p.Config.JsonContent)
+	configs = append(configs, "JsonContent: "+truncateContent(redactText(redactContent(p.Config.JsonContent, p.Config.RedactFields, p.Config.InputFormat)), p.Config.MaxPrintedContent))
 	configs = append(configs, "FailOnFailure: "+strconv.FormatBool(p.Config.FailOnFailure))
 	configs = append(configs, "NestedJsonList: "+strconv.FormatBool(p.Config.NestedJsonList))
+	configs = append(configs, "SuiteHierarchy: "+formatSuiteHierarchy(p.Config.SuiteHierarchy))
+	configs = append(configs, "SuiteHierarchyStyle: "+p.Config.SuiteHierarchyStyle)
+	configs = append(configs, "GroupBy: "+strings.Join(p.Config.GroupBy, ","))
+	configs = append(configs, "TestJUnitSystemOut: "+p.Config.TestJUnitSystemOut)
+	configs = append(configs, "TestJUnitSystemErr: "+p.Config.TestJUnitSystemErr)
+	configs = append(configs, "TestJUnitListSystemOut: "+p.Config.TestJUnitListSystemOut)
+	configs = append(configs, "TestJUnitListSystemErr: "+p.Config.TestJUnitListSystemErr)
+	configs = append(configs, "Properties: "+formatProperties(p.Config.Properties))
+	configs = append(configs, "SuiteProperties: "+formatProperties(p.Config.SuiteProperties))
+	configs = append(configs, "CaseProperties: "+formatProperties(p.Config.CaseProperties))
 	configs = append(configs, "TestJUnitSkipField: "+p.Config.TestJUnitSkipField)
+	configs = append(configs, "TestJUnitSkipMessage: "+p.Config.TestJUnitSkipMessage)
+	configs = append(configs, "FailWhen: "+p.Config.FailWhen)
+	configs = append(configs, "SkipWhen: "+p.Config.SkipWhen)
+	configs = append(configs, "ErrorWhen: "+p.Config.ErrorWhen)
+	configs = append(configs, "StatusField: "+p.Config.StatusField)
+	configs = append(configs, "StatusMap: "+formatOutcomeMap(p.Config.StatusMap))
+	configs = append(configs, "SeverityField: "+p.Config.SeverityField)
+	configs = append(configs, "SeverityMap: "+formatOutcomeMap(p.Config.SeverityMap))
+	configs = append(configs, "MappingEngine: "+p.Config.MappingEngine)
+	configs = append(configs, "MappingFile: "+p.Config.MappingFile)
+	configs = append(configs, "Preset: "+p.Config.Preset)
+	configs = append(configs, "InputFormat: "+p.Config.InputFormat)
+	configs = append(configs, "OutputFile: "+p.Config.OutputFile)
+	configs = append(configs, "OutputDir: "+p.Config.OutputDir)
+	configs = append(configs, "SplitSuites: "+strconv.FormatBool(p.Config.SplitSuites))
+	configs = append(configs, "LogLevel: "+p.Config.LogLevel)
+	configs = append(configs, "LogFormat: "+p.Config.LogFormat)
+	configs = append(configs, "RedactFields: "+strings.Join(p.Config.RedactFields, ","))
+	configs = append(configs, "RedactPatterns: "+strings.Join(p.Config.RedactPatterns, " "))
+	configs = append(configs, "MaxPrintedContent: "+strconv.Itoa(p.Config.MaxPrintedContent))
+	configs = append(configs, "MaxFailures: "+strconv.Itoa(p.Config.MaxFailures))
+	configs = append(configs, "MaxErrors: "+strconv.Itoa(p.Config.MaxErrors))
+	configs = append(configs, "MinScore: "+strconv.FormatFloat(p.Config.MinScore, 'f', -1, 64))
+	configs = append(configs, "SeverityLimits: "+formatSeverityLimits(p.Config.SeverityLimits))
+	configs = append(configs, "MaxSuiteFailures: "+strconv.Itoa(p.Config.MaxSuiteFailures))
+
+	if printTables {
+		fmt.Fprintln(console, "|---------------------------------------------------------------------------|")
+		fmt.Fprintln(console, "|                               Config                                      |")
+		fmt.Fprintln(console, "|---------------------------------------------------------------------------|")
+		for _, config := range configs {
+			// show the config name and value
+			fmt.Fprintln(console, "| "+redactText(config))
 
-	fmt.Println("|---------------------------------------------------------------------------|")
-	fmt.Println("|                               Config                                      |")
-	fmt.Println("|---------------------------------------------------------------------------|")
-	for _, config := range configs {
-		// show the config name and value
-		fmt.Println("| " + config)
-
-	}
-	// fmt.Println("Plugin executed with config:", p.Config)
-	fmt.Println("|---------------------------------------------------------------------------|")
-	fmt.Println("|---------------------------------------------------------------------------|")
-	fmt.Println("|                               Results                                     |")
-	fmt.Println("|---------------------------------------------------------------------------|")
-	fmt.Println(string(junitXML))
-	fmt.Println("-----------------------------------------------------------------------------")
-	printStatusTable(status)
-	// Check if should fail on errors
-	if p.Config.FailOnFailure {
-		// verify if there are errors in Testsuites object
-		if junitReport.TestSuite[0].Errors > 0 {
-			fmt.Println("Fail on Error Setting is True")
-			fmt.Println("Error: There are errors in the JUnit report.")
-			return fmt.Errorf("error: There are errors in the JUnit report")
 		}
-
+		// fmt.Println("Plugin executed with config:", p.Config)
+		fmt.Fprintln(console, "|---------------------------------------------------------------------------|")
+		fmt.Fprintln(console, "|---------------------------------------------------------------------------|")
+		fmt.Fprintln(console, "|                               Results                                     |")
+		fmt.Fprintln(console, "|---------------------------------------------------------------------------|")
+		if p.Config.OutputFile != StdoutFileName {
+			fmt.Fprintln(console, string(junitXML))
+		}
+		fmt.Fprintln(console, "-----------------------------------------------------------------------------")
+	}
+	logStatus(status)
+	// Check the quality gate across every suite of the report
+	gateResults := evaluateGate(junitReport, status, p.Config)
+	logGate(gateResults)
+	if err := gateError(gateResults); err != nil {
+		logger.Error("The quality gate failed", "rules", len(err.(*GateError).Failed))
+		return err
 	}
 
 	// Verify that the plugin works
-	fmt.Println("Plugin executed successfully!")
+	logger.Info("Plugin executed successfully!")
 
 	return nil
 }
@@ -263,448 +492,480 @@ func ParseJunit(jsonContent string, settings Config) (*Testsuites, error) {
 	// Add support to this json:
 	// [{"code":"DL3018","column":1,"file":"Dockerfile","level":"warning","line":4,"message":"Pin versions in apk add. Instead of `apk add <package>` use `apk add <package>=<version>`"},{"code":"DL3059","column":1,"file":"Dockerfile","level":"info","line":17,"message":"Multiple consecutive `RUN` instructions. Consider consolidation."}]
 
-	// JUnit conversion logic
-	failed := 0
-	total := 0 // Count for total test cases
-	testCases := []Testcase{}
-	errors := 0
-	newError := 0
-
-	// Parse the JSON content
-	var result map[string]interface{}
-	json.Unmarshal([]byte(jsonContent), &result)
-	var resultList []interface{}
-	json.Unmarshal([]byte(jsonContent), &resultList)
-	// fmt.Println("resultList: ", resultList)
+	// Validate every mapping setting before converting anything
+	if err := prepareMapping(settings); err != nil {
+		return nil, err
+	}
 
-	// Get the test suite name
-	testSuiteName, ok := result[settings.TestJUnitName].(string)
-	if !ok {
-		testSuiteName = settings.TestJUnitName
+	// Detect the format of the content when not known yet
+	format := settings.InputFormat
+	if format == InputFormatAuto {
+		format = sniffInputFormat([]byte(jsonContent))
+		logger.Info("Detected input format", "format", format)
+	}
+	if isStreamingFormat(format) {
+		settings.InputFormat = format
+		return parseStream(strings.NewReader(jsonContent), settings)
 	}
 
-	// Get the test suite description
-	testSuiteDescription, ok := result[settings.TestDescription].(string)
-	if !ok {
-		testSuiteDescription = settings.TestDescription
+	// Parse the JSON (or YAML/TOML) content
+	document, err := decodeDocument([]byte(jsonContent), format)
+	if err != nil {
+		return nil, err
 	}
+	document = redactFields(document, settings.RedactFields)
 
-	// Get the test suite time
-	testSuiteTime := 0
-	testSuiteTimeFloat, ok := result[settings.TestJUnitTime].(float64)
-	if ok {
-		testSuiteTime = int(testSuiteTimeFloat)
+	// Create the testsuites object
+	testSuites := &Testsuites{}
+
+	if len(settings.SuiteHierarchy) > 0 {
+		logger.Debug("Converting a suite hierarchy", "levels", formatSuiteHierarchy(settings.SuiteHierarchy))
+		// Every level of the hierarchy is a list of test suites, the last one holds the test cases
+		hierarchy, err := parseSuiteHierarchy(document, settings)
+		if err != nil {
+			return nil, err
+		}
+		testSuites.TestSuite = hierarchy
+	} else if settings.NestedJsonList {
+		logger.Debug("Converting a list of test suites", "NestedJsonList", true)
+		// Each element of the JSON list is a test suite with its own list of test cases
+		suiteList, ok := document.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("failed to parse nested JSON as a list of test suites")
+		}
+		for _, suiteRecord := range suiteList {
+			testSuite, err := parseTestSuite(suiteRecord, settings)
+			if err != nil {
+				return nil, err
+			}
+			testSuites.TestSuite = append(testSuites.TestSuite, testSuite)
+		}
 	} else {
-		fmt.Println("TestJUnitTime is not float64")
-		fmt.Println("TestJUnitTime: ", settings.TestJUnitTime)
-		testSuiteTimeInt, ok := result[settings.TestJUnitTime].(int)
-		if ok {
-			testSuiteTime = testSuiteTimeInt
-		} else {
-			if settings.TestJUnitTime != "" {
-				// fmt.Println("TestJUnitTime is not empty")
-				// fmt.Println("TestJUnitTime: ", settings.TestJUnitTime)
-				testSuiteTimeInt, err := strconv.Atoi(settings.TestJUnitTime)
-				if err != nil {
-					// return nil, fmt.Errorf("failed to parse TestJUnitTime as float64 or int")
-					fmt.Println("Error: failed to parse TestJUnitTime as float64 or int")
-					fmt.Println("Error: ", err)
-					fmt.Println("setting a default value of 0")
-					testSuiteTimeInt = 0
-				}
-				testSuiteTime = testSuiteTimeInt
-			} else {
-				// return nil, fmt.Errorf("failed to parse TestJUnitTime as float64 or int")
-				fmt.Println("Error: failed to parse TestJUnitTime as float64 or int")
-				fmt.Println("setting a default value of 0")
-				testSuiteTime = 0
+		logger.Debug("Converting a single test suite", "NestedJsonList", false)
+		testSuite, err := parseTestSuite(document, settings)
+		if err != nil {
+			return nil, err
+		}
+		testSuites.TestSuite = append(testSuites.TestSuite, testSuite)
+	}
+	testSuites.TestSuite = groupTestSuites(testSuites.TestSuite, settings)
 
+	status = newStatus(testSuites)
+
+	return testSuites, nil
+}
+
+// prepareMapping selects the mapping engine and compiles every mapping setting.
+func prepareMapping(settings Config) error {
+	if err := setMappingEngine(settings.MappingEngine); err != nil {
+		return err
+	}
+	if err := validateTimeUnit(settings.TimeUnit); err != nil {
+		return err
+	}
+	if err := validateTimeAggregation(settings.SuiteTimeAggregation); err != nil {
+		return err
+	}
+	if err := validateOutcomeMap(settings.SeverityMap); err != nil {
+		return fmt.Errorf("SeverityMap: %s", err)
+	}
+	if err := validateOutcomeMap(settings.StatusMap); err != nil {
+		return fmt.Errorf("StatusMap: %s", err)
+	}
+	if err := validateExpressions(map[string]string{
+		"FailWhen":  settings.FailWhen,
+		"SkipWhen":  settings.SkipWhen,
+		"ErrorWhen": settings.ErrorWhen,
+	}); err != nil {
+		return err
+	}
+	if err := validateSuiteHierarchy(settings.SuiteHierarchy, settings.SuiteHierarchyStyle); err != nil {
+		return fmt.Errorf("SuiteHierarchy: %s", err)
+	}
+	if err := validatePaths(propertyPaths("SuiteProperties", settings.SuiteProperties)); err != nil {
+		return err
+	}
+	if err := validatePaths(propertyPaths("CaseProperties", settings.CaseProperties)); err != nil {
+		return err
+	}
+	for i, field := range settings.GroupBy {
+		if err := validatePaths(map[string]string{fmt.Sprintf("GroupBy[%d]", i): field}); err != nil {
+			return err
+		}
+	}
+	for i, level := range settings.SuiteHierarchy {
+		if err := validatePaths(map[string]string{
+			fmt.Sprintf("SuiteHierarchy[%d].List", i): level.List,
+			fmt.Sprintf("SuiteHierarchy[%d].Name", i): level.Name,
+		}); err != nil {
+			return err
+		}
+	}
+	failureBody := settings.TestJUnitFailureBody
+	if failureBody == FailureBodyJSON || failureBody == FailureBodyYAML {
+		failureBody = ""
+	}
+	return validatePaths(map[string]string{
+		"TestJUnitName":          settings.TestJUnitName,
+		"TestDescription":        settings.TestDescription,
+		"TestJUnitTime":          settings.TestJUnitTime,
+		"TestJUnitTimestamp":     settings.TestJUnitTimestamp,
+		"TestJUnitHostname":      settings.TestJUnitHostname,
+		"TestJUnitList":          settings.TestJUnitList,
+		"TestJUnitListName":      settings.TestJUnitListName,
+		"TestJUnitListClassName": settings.TestJUnitListClassName,
+		"TestJUnitListFailure":   settings.TestJUnitListFailure,
+		"TestJUnitListTime":      settings.TestJUnitListTime,
+		"TestJUnitFailureBody":   failureBody,
+		"TestJUnitFailureType":   settings.TestJUnitFailureType,
+		"TestJUnitSystemOut":     settings.TestJUnitSystemOut,
+		"TestJUnitSystemErr":     settings.TestJUnitSystemErr,
+		"TestJUnitListSystemOut": settings.TestJUnitListSystemOut,
+		"TestJUnitListSystemErr": settings.TestJUnitListSystemErr,
+		"TestJUnitSkipField":     settings.TestJUnitSkipField,
+		"TestJUnitSkipMessage":   settings.TestJUnitSkipMessage,
+		"SeverityField":          settings.SeverityField,
+		"StatusField":            settings.StatusField,
+	})
+}
+
+// convert parses one input file, or JsonContent when file is empty, in the configured
+// input format.
+func (p *Plugin) convert(file string) (*Testsuites, error) {
+	settings := p.Config
+	if settings.InputFormat == InputFormatAuto && file != "" {
+		if format := formatFromExtension(file); format != "" {
+			settings.InputFormat = format
+		}
+	}
+
+	if isStreamingFormat(settings.InputFormat) {
+		var reader io.Reader = strings.NewReader(p.Config.JsonContent)
+		if file != "" {
+			input, err := OpenInput(file)
+			if err != nil {
+				return nil, fmt.Errorf("error reading JSON file: %s", err)
 			}
+			defer input.Close()
+			reader = input
 		}
+		return parseStream(reader, settings)
 	}
 
-	// Get the test suite list
-	var testSuiteList []interface{}
-	if settings.TestJUnitList != "." && !settings.NestedJsonList {
-		fmt.Println("TestJUnitList is not . and NestedJsonList is false")
-		testSuiteListInterface, ok := result[settings.TestJUnitList].([]interface{})
-		if !ok {
-			return nil, fmt.Errorf("failed to parse TestJUnitList as []interface{}")
+	jsonContent := p.Config.JsonContent
+	if file != "" {
+		jsonRead, err := ReadJSON(file)
+		if err != nil {
+			return nil, fmt.Errorf("error reading JSON file: %s", err)
 		}
-		fmt.Println("TestSuiteListInterface: ", testSuiteListInterface)
-		testSuiteList = testSuiteListInterface
-	} else {
-		fmt.Println("TestJUnitList is . or NestedJsonList is true")
-		testSuiteList = resultList
-		fmt.Println("Assigning resultList to testSuiteList")
+		jsonContent = jsonRead
 	}
+	return ParseJunit(jsonContent, settings)
+}
 
-	// Create the testsuites object
-	testSuites := &Testsuites{}
+// newStatus computes the JUnit status across all test suites. Skipped test cases are
+// neither passed nor failed and do not count in the score.
+func newStatus(testSuites *Testsuites) Status {
+	total := 0
+	failures := 0
+	errors := 0
+	skipped := 0
+	for _, testSuite := range testSuites.TestSuite {
+		total += testSuite.Tests
+		failures += testSuite.Failures
+		errors += testSuite.Errors
+		skipped += testSuite.Skipped
+	}
+	result := Status{Total: total, Passed: total - failures - errors - skipped, Failures: failures, Errors: errors, Skipped: skipped}
+	if executed := total - skipped; executed > 0 {
+		result.Score = float64(result.Passed) / float64(executed) * 100
+	}
+	return result
+}
+
+// labelTestSuites names the suites of one of several input files after the file when
+// their name is not taken from the JSON, so suites of different files can be told apart.
+func labelTestSuites(testSuites *Testsuites, file string, settings Config) {
+	for i := range testSuites.TestSuite {
+		switch testSuites.TestSuite[i].Name {
+		case "":
+			testSuites.TestSuite[i].Name = file
+		case settings.TestJUnitName:
+			testSuites.TestSuite[i].Name = settings.TestJUnitName + " - " + file
+		}
+	}
+}
+
+// parseTestSuite converts a JSON record into a Testsuite. Suite level settings are resolved
+// against the record, TestJUnitList must resolve to the list of test cases.
+func parseTestSuite(record interface{}, settings Config) (Testsuite, error) {
+	testSuite := newTestSuite(record, settings)
+	err := testSuite.addTestCaseList(record, settings)
+	return testSuite, err
+}
+
+// addTestCaseList adds every test case of the TestJUnitList of record to the suite.
+func (testSuite *Testsuite) addTestCaseList(record interface{}, settings Config) error {
+	testCaseList, ok := resolveList(record, settings.TestJUnitList)
+	if !ok {
+		return fmt.Errorf("failed to parse TestJUnitList %q as a list", settings.TestJUnitList)
+	}
+
+	for _, testCaseRecord := range testCaseList {
+		if err := testSuite.addTestCase(testCaseRecord, settings); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-	// Create the
-	// fmt.Println("len(testSuiteList): ", len(testSuiteList))
-	if len(testSuiteList) > 0 && settings.NestedJsonList {
-		// fmt.Println("len(testSuiteList) > 0 and NestedJsonList is true")
-		testSuites.TestSuite = make([]Testsuite, len(testSuiteList))
+// newTestSuite creates an empty Testsuite with the suite level settings resolved against record.
+func newTestSuite(record interface{}, settings Config) Testsuite {
+	testSuite := Testsuite{
+		Name:    resolveStringOr(record, settings.TestJUnitName, settings.TestJUnitName),
+		Package: resolveStringOr(record, settings.TestDescription, settings.TestDescription),
+	}
+	logger.Debug("Suite", "name", testSuite.Name)
+
+	// Get the test suite time, either from the JSON, as a fixed value or from the test cases
+	if seconds, ok := resolveSeconds(record, settings.TestJUnitTime, settings.TimeUnit); ok {
+		testSuite.Time = seconds
+	} else if seconds, ok := parseSeconds(settings.TestJUnitTime, settings.TimeUnit); ok {
+		testSuite.Time = seconds
 	} else {
-		// fmt.Println("len(testSuiteList) <= 0 or NestedJsonList is false")
-		testSuites.TestSuite = make([]Testsuite, 1)
-		// fmt.Println("len(testSuites.TestSuite): ", len(testSuites.TestSuite))
-	}
-	// testSuites.TestSuite = make([]Testsuite, len(testSuiteList)+1)
-	// fmt.Println("NestedJsonList: ", settings.NestedJsonList)
-	// fmt.Println("len(testSuites.TestSuite): ", len(testSuites.TestSuite))
-	if settings.NestedJsonList {
-		fmt.Println("NestedJsonList is true")
-		inc := 0
-		// Iterate over the test suites
-		for _, testSuite := range testSuiteList {
-			// fmt.Println("TestSuite: ", testSuite)
-			total++ // Increment the total test cases count
-			testCaseMap := testSuite.(map[string]interface{})
-			testSuiteName, ok := testCaseMap[settings.TestJUnitName].(string)
-			if !ok || testSuiteName == "" {
-				testSuiteName = settings.TestJUnitName
-			}
-			if !ok {
-				testSuiteName = settings.TestJUnitName
-			}
-			fmt.Println("Name: ", testSuiteName)
-			testSuites.TestSuite[inc].Name = testSuiteName
+		if settings.TestJUnitTime != "" {
+			logger.Warn("Failed to parse TestJUnitTime as a number or duration, using the test case times", "TestJUnitTime", settings.TestJUnitTime)
+		}
+		testSuite.timeAggregation = suiteTimeAggregation(settings)
+	}
 
-			testSuiteDescription, ok := testCaseMap[settings.TestDescription].(string)
-			if !ok {
-				testSuiteDescription = settings.TestDescription
-			}
-			fmt.Println("Description: ", testSuiteDescription)
-			testSuites.TestSuite[inc].Package = testSuiteDescription
-
-			testSuiteTime := 0
-			testSuiteTimeFloat, ok := testCaseMap[settings.TestJUnitTime].(float64)
-			if ok {
-				testSuiteTime = int(testSuiteTimeFloat)
-			} else {
-				fmt.Println("TestJUnitTime is not float64")
-				fmt.Println("TestJUnitTime: ", settings.TestJUnitTime)
-				testSuiteTimeInt, ok := testCaseMap[settings.TestJUnitTime].(int)
-				if ok {
-					testSuiteTime = testSuiteTimeInt
-				} else {
-					if settings.TestJUnitTime != "" {
-						fmt.Println("TestJUnitTime is not empty")
-						fmt.Println("TestJUnitTime: ", settings.TestJUnitTime)
-						testSuiteTimeInt, err := strconv.Atoi(settings.TestJUnitTime)
-						if err != nil {
-							// return nil, fmt.Errorf("failed to parse TestJUnitTime as float64 or int")
-							fmt.Println("Error: failed to parse TestJUnitTime as float64 or int")
-							fmt.Println("Error: ", err)
-							fmt.Println("setting a default value of 0")
-							testSuiteTimeInt = 0
-						}
-						testSuiteTime = testSuiteTimeInt
-					} else {
-						// return nil, fmt.Errorf("failed to parse TestJUnitTime as float64 or int")
-						fmt.Println("Error: failed to parse TestJUnitTime as float64 or int")
-						fmt.Println("setting a default value of 0")
-						testSuiteTime = 0
-
-					}
-				}
-			}
-			fmt.Println("Time: ", testSuiteTime)
-			testSuites.TestSuite[inc].Time = testSuiteTime
-
-			// Get the test suite list
-			var testSuiteList []interface{}
-			if settings.TestJUnitList != "." && !settings.NestedJsonList {
-				fmt.Println("TestJUnitList is not . and NestedJsonList is false")
-				testSuiteListInterface, ok := testCaseMap[settings.TestJUnitList].([]interface{})
-				if !ok {
-					return nil, fmt.Errorf("failed to parse TestJUnitList as []interface{}")
-				}
-				fmt.Println("TestSuiteListInterface: ", testSuiteListInterface)
-				testSuiteList = testSuiteListInterface
-			} else {
-				fmt.Println("TestJUnitList is . or NestedJsonList is true")
-				testSuiteList = resultList
-			}
+	testSuite.Properties = newProperties(record, settings.Properties, settings.SuiteProperties)
+	testSuite.SystemOut = resolveSystemLog(record, settings.TestJUnitSystemOut)
+	testSuite.SystemErr = resolveSystemLog(record, settings.TestJUnitSystemErr)
+	testSuite.Timestamp = resolveTimestamp(record, settings.TestJUnitTimestamp)
+	testSuite.Hostname = resolveStringOr(record, settings.TestJUnitHostname, settings.TestJUnitHostname)
+	if testSuite.Hostname == "" {
+		testSuite.Hostname, _ = os.Hostname()
+	}
 
-			testSuites.TestSuite[inc].Tests = len(testSuiteList)
+	return testSuite
+}
 
-			// Iterate over the test cases
+// addTestCase converts a JSON record into a Testcase of the suite and updates its counters.
+func (testSuite *Testsuite) addTestCase(record interface{}, settings Config) error {
+	logger.Debug("Case", "record", record)
+	testCase, ok, err := parseTestCase(record, settings)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		logger.Debug("TestJUnitListName not found, skipping case", "TestJUnitListName", settings.TestJUnitListName)
+		return nil
+	}
+	testCase.group = groupKey(record, settings.GroupBy)
+	testSuite.appendTestCase(testCase)
+	return nil
+}
 
-			fmt.Println("len(testSuiteList): ", len(testSuiteList))
+// appendTestCase adds a converted test case to the suite and updates its counters.
+func (testSuite *Testsuite) appendTestCase(testCase Testcase) {
+	if testCase.Failure != nil {
+		testSuite.Failures++
+	}
+	if testCase.Error != nil {
+		testSuite.Errors++
+	}
+	if testCase.Skipped != nil {
+		testSuite.Skipped++
+	}
+	testSuite.TestCase = append(testSuite.TestCase, testCase)
+	testSuite.Tests++
+	testSuite.aggregateTime(testCase.Time)
+}
 
-			testCases := []Testcase{}
+// aggregateTime adds the time of a test case (or child suite) to the suite time when
+// TestJUnitTime is not mapped.
+func (testSuite *Testsuite) aggregateTime(seconds float64) {
+	switch testSuite.timeAggregation {
+	case TimeAggregationSum:
+		testSuite.Time = math.Round((testSuite.Time+seconds)*1e9) / 1e9
+	case TimeAggregationMax:
+		testSuite.Time = math.Max(testSuite.Time, seconds)
+	}
+}
 
-			// Populate fields for a single test suite
-			singleTestSuite := Testsuite{
-				Name:    testSuiteName,
-				Package: testSuiteDescription,
-				Time:    testSuiteTime,
-				Tests:   len(testSuiteList),
+// parseTestCase converts a JSON record into a Testcase. ok is false when the record has no
+// test case name and must be ignored (nested lists only).
+func parseTestCase(record interface{}, settings Config) (Testcase, bool, error) {
+	testCase := Testcase{}
+
+	// kube-score style nested lists keep the name in a singular object named after the
+	// list, e.g. checks[].check.name, plain keys are looked up there first
+	nameScope := record
+	if settings.NestedJsonList && len(settings.SuiteHierarchy) == 0 && isPlainKey(settings.TestJUnitList) {
+		if recordMap, ok := record.(map[string]interface{}); ok {
+			if nested, ok := recordMap[strings.TrimSuffix(settings.TestJUnitList, "s")].(map[string]interface{}); ok {
+				nameScope = nested
 			}
+		}
+	}
 
-			fmt.Println("len(testSuiteList): ", len(testSuiteList))
-			// Add test cases (assuming they are in a list for each object)
-			testCaseList := testCaseMap[settings.TestJUnitList].([]interface{})
-			for _, testCase := range testCaseList {
-				fmt.Println("Case: ", testCase)
-
-				testCaseMap := testCase.(map[string]interface{})
-
-				// Remove "s" in the end of settings.TestJUnitList
-				nestedJson := settings.TestJUnitList[:len(settings.TestJUnitList)-1]
-				fmt.Println("nestedJson: ", nestedJson)
-				var testCaseObj Testcase
-				// var testCaseName, testCaseClassName string
-				// var testCaseTime int
-				// var checkMapMap map[string]interface{}
-
-				var time int
-
-				if tempTime, ok := testCaseMap[settings.TestJUnitListTime]; ok && tempTime != nil {
-					if timeFloat, ok := tempTime.(float64); ok {
-						time = int(timeFloat)
-					} else {
-						return nil, fmt.Errorf("failed to cast TestJUnitListTime to float64")
-					}
-				} else {
-					fmt.Println("settings.TestJUnitListTime: ", settings.TestJUnitListTime)
-					fmt.Println("Convert to int")
-					// var err error
-					// conver checkMap[settings.TestJUnitListTime] to int
-					fmt.Println("settings.TestJUnitListTime: ", settings.TestJUnitListTime)
-					fmt.Println("checkMap[settings.TestJUnitListTime]: ", testCaseMap[settings.TestJUnitListTime])
-					time = testCaseMap[settings.TestJUnitListTime].(int)
-
-					// time, err = checkMap[settings.TestJUnitListTime].(int)
-					// if err != nil {
-					// 	return nil, fmt.Errorf("failed to parse TestJUnitListTime as float64 or int")
-					// }
-					// return nil, fmt.Errorf("TestJUnitListTime is either nil or not found in checkMap")
-				}
-
-				if checkMap, ok := testCaseMap[nestedJson].(map[string]interface{}); ok {
-
-					// check if checkMap["map"] is null or empty
-					// if it is, then checkMap["map"] = checkMap
-					// if it is not, then checkMap["map"] = checkMap["map"]
-					// checkMap["map"] = checkMap
-					// checkMap["map"] = checkMap["map"]
-					fmt.Println("checkMap: ", checkMap)
-					if checkMap[settings.TestJUnitListName] == nil {
-						// checkMap["map"] = checkMap
-						//skip the loop
-						fmt.Println("checkMap[" + settings.TestJUnitListName + "] is nil")
-						fmt.Println("Continuing...")
-						continue
-
-					}
-
-					// checkMapMap = checkMap["map"].(map[string]interface{})
-
-					// fmt.Println("checkMapMap: ", checkMapMap)
-					// fmt.Println("settings: " + settings.TestJUnitListName)
-					// fmt.Println("checkMapMap[settings.TestJUnitListName]: ", checkMapMap[settings.TestJUnitListName])
-					fmt.Println("checkMap[settings.TestJUnitListName]: ", checkMap[settings.TestJUnitListName])
-					var name, classname string
-
-					if tempName, ok := checkMap[settings.TestJUnitListName]; ok && tempName != nil {
-						if nameStr, ok := tempName.(string); ok {
-							name = nameStr
-						} else {
-							return nil, fmt.Errorf("failed to cast TestJUnitListName to string")
-						}
-					} else {
-						return nil, fmt.Errorf("TestJUnitListName is either nil or not found in checkMap")
-					}
-
-					if tempClass, ok := checkMap[settings.TestJUnitListClassName]; ok && tempClass != nil {
-						if classStr, ok := tempClass.(string); ok {
-							classname = classStr
-						} else {
-							return nil, fmt.Errorf("failed to cast TestJUnitListClassName to string")
-						}
-					} else {
-						return nil, fmt.Errorf("TestJUnitListClassName is either nil or not found in checkMap")
-					}
-
-					testCaseObj = Testcase{
-						Name:      name,
-						Classname: classname,
-						Time:      time,
-					}
-
-				}
-				var failureMessage string
-				fmt.Println("settings.TestJUnitListFailure: ", settings.TestJUnitListFailure)
-				var skipFieldList bool
-				if settings.TestJUnitSkipField != "" {
-					skipFieldList = testCaseMap[settings.TestJUnitSkipField].(bool)
-				} else {
-					skipFieldList = false
-				}
-				error := 0
-				if strings.Contains(settings.TestJUnitListFailure, "[]") && !skipFieldList {
-					fmt.Println("Failures are in a list")
-
-					// This is a list, handle accordingly
-					failureMessageList := []string{}
-					// split the string by [] and get the first part
-					nestedList := strings.Split(settings.TestJUnitListFailure, "[]")
-					nestedListFirst := nestedList[0]
-					splitedNestedListSecond := strings.Split(nestedList[1], ".")
-					nestedListSecond := splitedNestedListSecond[1]
-					fmt.Println("nestedListFirst: ", nestedListFirst)
-					fmt.Println("nestedListSecond: ", nestedListSecond)
-
-					errs := 0
-					if commentsList, ok := testCaseMap[nestedListFirst].([]interface{}); ok {
-						fmt.Println("commentsList: ", commentsList)
-						if len(commentsList) > 0 {
-							errs = len(commentsList)
-						}
-						for _, comment := range commentsList {
-							if commentMap, ok := comment.(map[string]interface{}); ok {
-								if summary, ok := commentMap[nestedListSecond].(string); ok {
-									failureMessageList = append(failureMessageList, summary)
-								}
-							}
-						}
-					}
-					if errs > 0 {
-						failureMessage = strings.Join(failureMessageList, "; ")
-						error++
-					}
-				} else {
-
-					if !skipFieldList {
-						// Existing single value handling
-						if testCaseMap[settings.TestJUnitListFailure] != nil {
-							failureMessage = testCaseMap[settings.TestJUnitListFailure].(string)
-							error++
-						}
-						// else {
-						// 	failureMessage = "Unknown Error"
-						// 	fmt.Println("Error: TestJUnitListFailure is nil")
-						// 	fmt.Println("Error: ", testCaseMap[settings.TestJUnitListFailure])
-						// 	fmt.Println("Error: ", testCaseMap[settings.TestJUnitListFailure].(string))
-
-						// }
-					}
-				}
-				if !skipFieldList && error > 0 {
-					failed++
-					errors++
-					testCaseObj.Failure = &Failure{Message: failureMessage}
-				}
-
-				testCases = append(testCases, testCaseObj)
+	name, ok := resolveString(nameScope, settings.TestJUnitListName)
+	if !ok && isPlainKey(settings.TestJUnitListName) {
+		name, ok = resolveString(record, settings.TestJUnitListName)
+	}
+	if !ok {
+		if settings.NestedJsonList {
+			return testCase, false, nil
+		}
+		return testCase, false, fmt.Errorf("TestJUnitListName %q not found in test case", settings.TestJUnitListName)
+	}
+	testCase.Name = name
 
-			}
+	classname, ok := resolveString(nameScope, settings.TestJUnitListClassName)
+	if !ok && isPlainKey(settings.TestJUnitListClassName) {
+		classname, ok = resolveString(record, settings.TestJUnitListClassName)
+	}
+	if !ok {
+		return testCase, false, fmt.Errorf("TestJUnitListClassName %q not found in test case", settings.TestJUnitListClassName)
+	}
+	testCase.Classname = classname
+
+	// Test case time, either a fixed value or a JSON field
+	if seconds, ok := parseSeconds(settings.TestJUnitListTime, settings.TimeUnit); ok {
+		testCase.Time = seconds
+	} else if seconds, ok := resolveSeconds(record, settings.TestJUnitListTime, settings.TimeUnit); ok {
+		testCase.Time = seconds
+	}
+
+	testCase.Properties = newProperties(record, nil, settings.CaseProperties)
+	testCase.SystemOut = resolveSystemLog(record, settings.TestJUnitListSystemOut)
+	testCase.SystemErr = resolveSystemLog(record, settings.TestJUnitListSystemErr)
 
-			// fmt.Println("errors: ", errors)
-			// fmt.Println("newError: ", newError)
-			// fmt.Println("failed: ", failed)
-			// fmt.Println("total: ", total)
-			// fmt.Println("testCases: ", testCases)
-			singleTestSuite.Errors = errors
-			singleTestSuite.TestCase = testCases
-
-			// Append this singleTestSuite to the main testSuites object.
-			testSuites.TestSuite = append(testSuites.TestSuite, singleTestSuite)
-			inc++
+	// Skipped cases are reported as <skipped> instead of a failure
+	skip, _ := resolveBool(record, settings.TestJUnitSkipField)
+	skipWhen, _, err := evaluateExpression(record, settings.SkipWhen, settings.InputFormat)
+	if err != nil {
+		return testCase, false, fmt.Errorf("skip_when: test case %q: %s", testCase.Name, err)
+	}
+	if skip || skipWhen {
+		testCase.Skipped = &Skipped{Message: resolveStringOr(record, settings.TestJUnitSkipMessage, settings.TestJUnitSkipMessage)}
+		return testCase, true, nil
+	}
+
+	failureMessage, failed := resolveFailure(record, settings.TestJUnitListFailure)
+	// FailWhen replaces the presence of a failure value as failure condition
+	failWhen, ok, err := evaluateExpression(record, settings.FailWhen, settings.InputFormat)
+	if err != nil {
+		return testCase, false, fmt.Errorf("fail_when: test case %q: %s", testCase.Name, err)
+	}
+	if ok {
+		failed = failWhen
+		if failureMessage == "" {
+			failureMessage = settings.FailWhen
 		}
-	} else {
-		fmt.Println("NestedJsonList is false")
-		fmt.Println("Suite Name: ", testSuiteName)
-		testSuites.TestSuite[0].Name = testSuiteName
-		testSuites.TestSuite[0].Package = testSuiteDescription
-		testSuites.TestSuite[0].Time = testSuiteTime
-		testSuites.TestSuite[0].Tests = len(testSuiteList)
-
-		// Iterate over the test cases
-		for _, testCase := range testSuiteList {
-			total++ // Increment the total test cases count
-			fmt.Println("Case: ", testCase)
-			testCaseMap := testCase.(map[string]interface{})
-			testCaseName := testCaseMap[settings.TestJUnitListName].(string)
-			testCaseClassName := testCaseMap[settings.TestJUnitListClassName].(string)
-			var testCaseFailure string
-			if tempFailure, ok := testCaseMap[settings.TestJUnitListFailure]; ok && tempFailure != nil {
-				testCaseFailure = testCaseMap[settings.TestJUnitListFailure].(string)
-			}
+	}
+	outcome := OutcomePass
+	if failed {
+		outcome = OutcomeFailure
+	}
 
-			// fmt.Println("settings.TestJUnitListTime: ", settings.TestJUnitListTime)
-			var testCaseTime int
-			// test if settings.TestJUnitListTime is a int or float64
-			timeInt, err := strconv.Atoi(settings.TestJUnitListTime)
-			// fmt.Println("timeInt: ", timeInt)
-			if settings.TestJUnitListTime != "" {
-				if err == nil {
-					testCaseTime = timeInt
-				} else if tempTime, ok := testCaseMap[settings.TestJUnitListTime]; ok && tempTime != nil {
-					if timeFloat, ok := tempTime.(float64); ok {
-						testCaseTime = int(timeFloat)
-					} else {
-						testCaseTime = 0
-					}
-				} else {
-					fmt.Println("settings.TestJUnitListTime: ", settings.TestJUnitListTime)
-					fmt.Println("Convert to int")
-					// var err error
-					// conver checkMap[settings.TestJUnitListTime] to int
-					fmt.Println("settings.TestJUnitListTime: ", settings.TestJUnitListTime)
-					fmt.Println("checkMap[settings.TestJUnitListTime]: ", testCaseMap[settings.TestJUnitListTime])
-					if timeFloat, ok := tempTime.(int); ok {
-						testCaseTime = timeFloat
-					} else {
-						testCaseTime = 0
-					}
-				}
+	// A mapped status (e.g. passed, failed, broken) is the verdict of a test runner
+	if value, ok := resolveString(record, settings.StatusField); ok {
+		if mapped, ok := lookupOutcome(statusMap(settings), value); ok {
+			outcome = mapped
+			if outcome == OutcomeSkipped {
+				failureMessage = resolveStringOr(record, settings.TestJUnitSkipMessage, settings.TestJUnitSkipMessage)
 			}
-			// testCaseTime = int(testCaseMap[settings.TestJUnitListTime].(float64))
-			// fmt.Println("testCaseTime: ", testCaseTime)
-			// Create the testcase object
-			testCaseObj := Testcase{
-				Name:      testCaseName,
-				Classname: testCaseClassName,
-				Time:      testCaseTime,
+			if failureMessage == "" {
+				failureMessage = value
 			}
+		} else {
+			logger.Warn("Status is not mapped, keeping the outcome", "status", value, "outcome", outcome)
+		}
+	}
 
-			// Check if the test case failed
-			if testCaseFailure != "" {
-				failed++ // Increment the failed test cases count
-				errors++
-				newError++
-				testCaseObj.Failure = &Failure{Message: testCaseFailure}
+	// A mapped severity decides between failure, error, skipped and pass
+	if severity, ok := resolveString(record, settings.SeverityField); ok {
+		testCase.severity = severity
+		if mapped, ok := lookupOutcome(settings.SeverityMap, severity); ok {
+			outcome = mapped
+			if failureMessage == "" {
+				failureMessage = severity
 			}
-
-			// Add the testcase to the testsuite
-			testCases = append(testCases, testCaseObj)
 		}
+	}
 
-		// Update the testsuite object
-		testSuites.TestSuite[0].Errors = errors
-		testSuites.TestSuite[0].TestCase = testCases
-
-		// Print or return the total and failed test cases count
-		// fmt.Printf("Total test cases: %d\n", total)
-		// fmt.Printf("Failed test cases: %d\n", failed)
+	errorWhen, _, err := evaluateExpression(record, settings.ErrorWhen, settings.InputFormat)
+	if err != nil {
+		return testCase, false, fmt.Errorf("error_when: test case %q: %s", testCase.Name, err)
 	}
+	if errorWhen {
+		outcome = OutcomeError
+		if failureMessage == "" || failureMessage == settings.FailWhen {
+			failureMessage = settings.ErrorWhen
+		}
+	}
+	setOutcome(&testCase, outcome, failureMessage)
+	setFailureDetails(&testCase, record, settings)
 
-	status = Status{Total: total, Passed: total - failed, Errors: errors, Score: float64(total-failed) / float64(total) * 100}
+	return testCase, true, nil
+}
 
-	// printStatusTable(Status{Total: total, Passed: total - failed, Errors: errors, Score: float64(total-failed) / float64(total) * 100})
+// resolveFailure reports whether the failure setting matched anything. A list (e.g.
+// comments[].summary) is a failure when it is not empty, a string when it is not blank.
+func resolveFailure(record interface{}, path string) (string, bool) {
+	value, ok := resolvePath(record, path)
+	if !ok {
+		return "", false
+	}
+	switch v := value.(type) {
+	case []interface{}:
+		return stringify(v), len(v) > 0
+	case bool:
+		if v {
+			return path, true
+		}
+		return "", false
+	default:
+		message := stringify(v)
+		return message, message != ""
+	}
+}
 
-	return testSuites, nil
+// resolveSystemLog resolves a system-out or system-err setting, the elements of a list
+// (e.g. comments[].description) are written one per line.
+func resolveSystemLog(record interface{}, path string) *SystemLog {
+	value, ok := resolvePath(record, path)
+	if !ok {
+		return nil
+	}
+	lines := []string{}
+	if list, ok := value.([]interface{}); ok {
+		for _, item := range list {
+			if line := stringify(item); line != "" {
+				lines = append(lines, line)
+			}
+		}
+	} else if text := stringify(value); text != "" {
+		lines = append(lines, text)
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	return &SystemLog{Text: strings.Join(lines, "\n")}
 }
 
 func ReadJSON(filename string) (string, error) {
 
-	// Read the JSON file and return its contents as a string
+	// Read the JSON file (or stdin for "-") and return its contents as a string
+	if filename == StdinFileName {
+		result, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", err
+		}
+		return string(result), nil
+	}
 	result, err := os.ReadFile(filename)
 	if err != nil {
 		return "", err
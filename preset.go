@@ -0,0 +1,40 @@
+package main
+
+// Presets are mapping files bundled in the binary for the JSON output of common tools,
+// so "preset: kube-score" replaces the whole list of test_junit_* settings. Each preset
+// lives in presets/<name>.yaml and uses the same format as --mapping_file.
+
+import (
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+//go:embed presets/*.yaml
+var presetFiles embed.FS
+
+// Presets returns the names of the bundled presets.
+func Presets() []string {
+	entries, _ := presetFiles.ReadDir("presets")
+	names := []string{}
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".yaml"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadPreset returns the bundled mapping of the given tool.
+func LoadPreset(name string) (*Mapping, error) {
+	content, err := presetFiles.ReadFile(path.Join("presets", name+".yaml"))
+	if err != nil {
+		return nil, fmt.Errorf("unknown preset %q, available presets: %s", name, strings.Join(Presets(), ", "))
+	}
+	mapping, err := ParseMapping(content)
+	if err != nil {
+		return nil, fmt.Errorf("invalid preset %s: %s", name, err)
+	}
+	return mapping, nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"io"
+	"os"
+	"testing"
+)
+
+func TestPresetFixtures(t *testing.T) {
+	if err := setupLogging("error", LogFormatText, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		preset   string
+		fixture  string
+		suites   int
+		tests    int
+		failures int
+		errors   int
+		skipped  int
+	}{
+		{preset: "hadolint", fixture: "tests/hadolint.json", suites: 1, tests: 4, failures: 2, errors: 1, skipped: 1},
+		{preset: "kube-score", fixture: "tests/nested-json.json", suites: 3, tests: 30, failures: 7, errors: 0, skipped: 9},
+		{preset: "trivy", fixture: "tests/trivy.json", suites: 1, tests: 3, failures: 3, errors: 0, skipped: 0},
+	}
+
+	covered := map[string]bool{}
+	for _, tt := range tests {
+		covered[tt.preset] = true
+		t.Run(tt.preset, func(t *testing.T) {
+			mapping, err := LoadPreset(tt.preset)
+			if err != nil {
+				t.Fatalf("LoadPreset: %s", err)
+			}
+			settings := Config{}
+			mapping.Apply(&settings)
+
+			content, err := os.ReadFile(tt.fixture)
+			if err != nil {
+				t.Fatal(err)
+			}
+			report, err := ParseJunit(string(content), settings)
+			if err != nil {
+				t.Fatalf("ParseJunit: %s", err)
+			}
+
+			if len(report.TestSuite) != tt.suites {
+				t.Errorf("suites = %d, want %d", len(report.TestSuite), tt.suites)
+			}
+			got := newStatus(report)
+			if got.Total != tt.tests || got.Failures != tt.failures || got.Errors != tt.errors || got.Skipped != tt.skipped {
+				t.Errorf("tests/failures/errors/skipped = %d/%d/%d/%d, want %d/%d/%d/%d",
+					got.Total, got.Failures, got.Errors, got.Skipped, tt.tests, tt.failures, tt.errors, tt.skipped)
+			}
+		})
+	}
+
+	for _, preset := range Presets() {
+		if !covered[preset] {
+			t.Errorf("preset %s has no fixture test", preset)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+// Test suites and test cases can carry <properties> for the tools reading the JUnit report:
+//
+//   - Properties: static name=value pairs added to every suite, e.g. the pipeline ID or
+//     commit SHA: "pipeline=<+pipeline.executionId>,commit=<+codebase.commitSha>"
+//   - SuiteProperties: name=path pairs resolved against the suite record
+//   - CaseProperties: name=path pairs resolved against each test case record, e.g.
+//     "severity=level,rule_id=code"
+//
+// Mapped properties that do not resolve are left out. Properties are written sorted by name.
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ParseProperties parses a "name=value,name=value" list.
+func ParseProperties(value string) (map[string]string, error) {
+	properties := map[string]string{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("invalid property %q, expected name=value", entry)
+		}
+		properties[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	return properties, nil
+}
+
+// formatProperties renders properties back to the "name=value" form.
+func formatProperties(properties map[string]string) string {
+	entries := []string{}
+	for name, value := range properties {
+		entries = append(entries, name+"="+value)
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, ",")
+}
+
+// newProperties builds the <properties> element from static values and mapped paths
+// resolved against record, nil when there are none.
+func newProperties(record interface{}, static map[string]string, mapped map[string]string) *Properties {
+	values := map[string]string{}
+	for name, value := range static {
+		values[name] = value
+	}
+	for name, path := range mapped {
+		if value, ok := resolveString(record, path); ok {
+			values[name] = value
+		}
+	}
+	if len(values) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	properties := &Properties{}
+	for _, name := range names {
+		properties.Property = append(properties.Property, Property{Name: name, Value: values[name]})
+	}
+	return properties
+}
+
+// propertyPaths returns the mapped properties keyed by setting name, for validatePaths.
+func propertyPaths(setting string, mapped map[string]string) map[string]string {
+	paths := map[string]string{}
+	for name, path := range mapped {
+		paths[setting+"."+name] = path
+	}
+	return paths
+}
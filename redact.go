@@ -0,0 +1,246 @@
+package main
+
+// Scanner output may embed secrets (tokens in a finding, credentials in a config file), so
+// they are redacted before they reach the JUnit report or the CI logs:
+//
+//   - RedactFields: fields replaced by "[REDACTED]" wherever they appear in the input, e.g.
+//     "secret,match" for a secret scanner
+//   - known secret patterns (cloud and SaaS tokens, private keys, JWTs, "password=..."
+//     assignments) and RedactPatterns, extra regular expressions one per line, are redacted
+//     from suite and test case names, failure messages and bodies, system-out/err,
+//     properties, the config table and the logs (see logging.go); a pattern with "key"
+//     and "sep" named groups keeps them, e.g. "password=[REDACTED]"
+//
+// The printed JsonContent has RedactFields applied too and is truncated to MaxPrintedContent
+// characters (500 by default, negative to print it whole).
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const redactedText = "[REDACTED]"
+
+// DefaultMaxPrintedContent is the length of the JsonContent printed in the config table.
+const DefaultMaxPrintedContent = 500
+
+var knownSecretPatterns = []string{
+	`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`,
+	`\b(AKIA|ASIA)[0-9A-Z]{16}\b`,                            // AWS access key ID
+	`\bgh[pousr]_[A-Za-z0-9]{36,}\b`,                         // GitHub token
+	`\bgithub_pat_[A-Za-z0-9_]{22,}\b`,                       // GitHub fine-grained token
+	`\bglpat-[A-Za-z0-9_-]{20,}\b`,                           // GitLab token
+	`\bxox[abposr]-[A-Za-z0-9-]{10,}\b`,                      // Slack token
+	`\bAIza[0-9A-Za-z_-]{35}\b`,                              // Google API key
+	`\bsk_live_[0-9A-Za-z]{24,}\b`,                           // Stripe key
+	`\beyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`, // JWT
+	`(?i)\bbearer\s+[A-Za-z0-9._~+/-]{16,}=*`,
+	`(?i)\b(?P<key>pass(?:word|wd)?|secret|token|api[_-]?key|access[_-]?key)(?P<sep>["']?\s*[:=]\s*["']?)[^\s"',;&]+`,
+}
+
+// redactPatterns holds the compiled known and RedactPatterns expressions, set by setRedactPatterns.
+var redactPatterns []*regexp.Regexp
+
+// ParseRedactPatterns splits the RedactPatterns setting, one regular expression per line.
+func ParseRedactPatterns(value string) []string {
+	patterns := []string{}
+	for _, line := range strings.Split(value, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			patterns = append(patterns, line)
+		}
+	}
+	return patterns
+}
+
+// setRedactPatterns compiles the known secret patterns and the RedactPatterns setting.
+func setRedactPatterns(patterns []string) error {
+	compiled := []*regexp.Regexp{}
+	for _, pattern := range knownSecretPatterns {
+		compiled = append(compiled, regexp.MustCompile(pattern))
+	}
+	for _, pattern := range patterns {
+		expression, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid redact pattern %q: %s", pattern, err)
+		}
+		compiled = append(compiled, expression)
+	}
+	redactPatterns = compiled
+	return nil
+}
+
+// redactText replaces every secret pattern match of text. Key/value assignments keep their key.
+func redactText(text string) string {
+	for _, pattern := range redactPatterns {
+		if pattern.SubexpIndex("key") >= 0 && pattern.SubexpIndex("sep") >= 0 {
+			text = pattern.ReplaceAllString(text, "${key}${sep}"+redactedText)
+		} else {
+			text = pattern.ReplaceAllString(text, redactedText)
+		}
+	}
+	return text
+}
+
+// redactFields replaces the values of the given fields, at any depth of data.
+func redactFields(data interface{}, fields []string) interface{} {
+	if len(fields) == 0 {
+		return data
+	}
+	switch v := data.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if isRedactedField(key, fields) {
+				v[key] = redactedText
+			} else {
+				v[key] = redactFields(value, fields)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactFields(item, fields)
+		}
+	}
+	return data
+}
+
+func isRedactedField(key string, fields []string) bool {
+	for _, field := range fields {
+		if strings.EqualFold(key, field) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactContent applies RedactFields to the printed JsonContent, re-serialized as JSON
+// (one line per record for NDJSON, rows for CSV/TSV). Content that cannot be parsed is
+// redacted whole.
+func redactContent(content string, fields []string, format string) string {
+	if len(fields) == 0 || strings.TrimSpace(content) == "" {
+		return content
+	}
+	if format == "" || format == InputFormatAuto {
+		format = sniffInputFormat([]byte(content))
+	}
+	switch format {
+	case InputFormatNDJSON:
+		lines := strings.Split(content, "\n")
+		for i, line := range lines {
+			if strings.TrimSpace(line) != "" {
+				lines[i] = redactDocument(line, fields, InputFormatJSON)
+			}
+		}
+		return strings.Join(lines, "\n")
+	case InputFormatCSV, InputFormatTSV:
+		return redactTable(content, fields, format)
+	default:
+		return redactDocument(content, fields, format)
+	}
+}
+
+func redactDocument(content string, fields []string, format string) string {
+	document, err := decodeDocument([]byte(content), format)
+	if err != nil {
+		return redactedText
+	}
+	var output bytes.Buffer
+	encoder := json.NewEncoder(&output)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(redactFields(document, fields)); err != nil {
+		return redactedText
+	}
+	return strings.TrimSuffix(output.String(), "\n")
+}
+
+// redactTable replaces the cells of the redacted columns of CSV/TSV content.
+func redactTable(content string, fields []string, format string) string {
+	reader := csv.NewReader(strings.NewReader(content))
+	reader.FieldsPerRecord = -1
+	if format == InputFormatTSV {
+		reader.Comma = '\t'
+		reader.LazyQuotes = true
+	}
+	rows, err := reader.ReadAll()
+	if err != nil || len(rows) == 0 {
+		return redactedText
+	}
+	var output strings.Builder
+	writer := csv.NewWriter(&output)
+	writer.Comma = reader.Comma
+	for i, row := range rows {
+		if i > 0 {
+			for j := range row {
+				if j < len(rows[0]) && isRedactedField(strings.TrimSpace(rows[0][j]), fields) {
+					row[j] = redactedText
+				}
+			}
+		}
+		if err := writer.Write(row); err != nil {
+			return redactedText
+		}
+	}
+	writer.Flush()
+	return strings.TrimSuffix(output.String(), "\n")
+}
+
+// redactReport redacts the free text of every test suite and test case of a report.
+func redactReport(testSuites []Testsuite) {
+	for i := range testSuites {
+		testSuite := &testSuites[i]
+		testSuite.Name = redactText(testSuite.Name)
+		testSuite.Package = redactText(testSuite.Package)
+		redactReport(testSuite.TestSuite)
+		redactProperties(testSuite.Properties)
+		redactSystemLog(testSuite.SystemOut)
+		redactSystemLog(testSuite.SystemErr)
+		for j := range testSuite.TestCase {
+			testCase := &testSuite.TestCase[j]
+			testCase.Name = redactText(testCase.Name)
+			testCase.Classname = redactText(testCase.Classname)
+			for _, failure := range []*Failure{testCase.Failure, testCase.Error} {
+				if failure != nil {
+					failure.Message = redactText(failure.Message)
+					failure.Text = redactText(failure.Text)
+				}
+			}
+			if testCase.Skipped != nil {
+				testCase.Skipped.Message = redactText(testCase.Skipped.Message)
+			}
+			redactProperties(testCase.Properties)
+			redactSystemLog(testCase.SystemOut)
+			redactSystemLog(testCase.SystemErr)
+		}
+	}
+}
+
+func redactProperties(properties *Properties) {
+	if properties == nil {
+		return
+	}
+	for i := range properties.Property {
+		properties.Property[i].Value = redactText(properties.Property[i].Value)
+	}
+}
+
+func redactSystemLog(systemLog *SystemLog) {
+	if systemLog != nil {
+		systemLog.Text = redactText(systemLog.Text)
+	}
+}
+
+// truncateContent shortens printed content to length characters, noting the full size.
+func truncateContent(content string, length int) string {
+	if length == 0 {
+		length = DefaultMaxPrintedContent
+	}
+	runes := []rune(content)
+	if length < 0 || len(runes) <= length {
+		return content
+	}
+	return string(runes[:length]) + "... (" + strconv.Itoa(len(content)) + " bytes)"
+}
@@ -0,0 +1,150 @@
+package main
+
+// A mapping setting containing "{{" is a Go text/template rendered against the record
+// instead of a path, so names can combine several fields:
+//
+//   --test_junit_list_name '{{.code}} {{.file}}:{{.line}}'
+//   --test_junit_list_failure '{{if .message}}{{.message | truncate 80}}{{end}}'
+//
+// Besides the text/template builtins these functions are available:
+//
+//   - truncate N VALUE          the first N characters of VALUE, "..." marks the cut
+//   - lower VALUE, upper VALUE  case conversion
+//   - trim VALUE                removes leading and trailing spaces
+//   - replace REGEX NEW VALUE   regexp.ReplaceAllString, NEW may use $1
+//   - basename VALUE            the last element of a path, "src/app/main.go" -> "main.go"
+//   - default FALLBACK VALUE    FALLBACK when VALUE is empty
+//   - json VALUE                VALUE rendered as JSON, e.g. ["a","b"] or "text"
+//
+// Missing fields render as an empty string, an empty result counts as not found.
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+var templateCache = map[string]*template.Template{}
+
+var templateFuncs = template.FuncMap{
+	"truncate": func(length int, value interface{}) string {
+		runes := []rune(stringify(value))
+		if length < 0 || len(runes) <= length {
+			return string(runes)
+		}
+		if length <= 3 {
+			return string(runes[:length])
+		}
+		return string(runes[:length-3]) + "..."
+	},
+	"lower": func(value interface{}) string { return strings.ToLower(stringify(value)) },
+	"upper": func(value interface{}) string { return strings.ToUpper(stringify(value)) },
+	"trim":  func(value interface{}) string { return strings.TrimSpace(stringify(value)) },
+	"replace": func(pattern string, replacement string, value interface{}) (string, error) {
+		expression, err := regexp.Compile(pattern)
+		if err != nil {
+			return "", err
+		}
+		return expression.ReplaceAllString(stringify(value), replacement), nil
+	},
+	"basename": func(value interface{}) string {
+		return path.Base(strings.ReplaceAll(stringify(value), "\\", "/"))
+	},
+	"default": func(fallback interface{}, value interface{}) interface{} {
+		if stringify(value) == "" {
+			return fallback
+		}
+		return value
+	},
+	"json": func(value interface{}) (string, error) {
+		var content bytes.Buffer
+		encoder := json.NewEncoder(&content)
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(value); err != nil {
+			return "", err
+		}
+		return strings.TrimSuffix(content.String(), "\n"), nil
+	},
+	// appended to every printed pipeline by compileTemplate
+	missingValueFunc: func(value interface{}) interface{} {
+		if value == nil {
+			return ""
+		}
+		return value
+	},
+}
+
+// missingValueFunc renders missing fields and nulls as "" instead of "<no value>".
+const missingValueFunc = "missingValue"
+
+// isTemplate reports whether a mapping setting is a text/template.
+func isTemplate(setting string) bool {
+	return strings.Contains(setting, "{{")
+}
+
+// compileTemplate parses a template setting and caches it.
+func compileTemplate(text string) (*template.Template, error) {
+	if compiled, ok := templateCache[text]; ok {
+		return compiled, nil
+	}
+	compiled, err := template.New("mapping").Funcs(templateFuncs).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template %q: %s", text, err)
+	}
+	for _, tree := range compiled.Templates() {
+		if tree.Tree != nil {
+			printMissingAsEmpty(tree.Tree, tree.Tree.Root)
+		}
+	}
+	templateCache[text] = compiled
+	return compiled, nil
+}
+
+// renderTemplate executes a template setting against data. ok is false when the result is empty.
+func renderTemplate(data interface{}, text string) (string, bool) {
+	compiled, err := compileTemplate(text)
+	if err != nil {
+		return "", false
+	}
+	var output strings.Builder
+	if err := compiled.Execute(&output, data); err != nil {
+		logger.Warn("Failed to render template", "template", text, "error", err)
+		return "", false
+	}
+	rendered := output.String()
+	return rendered, strings.TrimSpace(rendered) != ""
+}
+
+// printMissingAsEmpty appends missingValueFunc to every action printing a value: missing
+// keys of a map[string]interface{} evaluate to nil even with missingkey=zero, and nil
+// prints as "<no value>".
+func printMissingAsEmpty(tree *parse.Tree, node parse.Node) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			printMissingAsEmpty(tree, child)
+		}
+	case *parse.ActionNode:
+		if len(n.Pipe.Decl) == 0 {
+			identifier := parse.NewIdentifier(missingValueFunc).SetTree(tree).SetPos(n.Pos)
+			n.Pipe.Cmds = append(n.Pipe.Cmds, &parse.CommandNode{NodeType: parse.NodeCommand, Pos: n.Pos, Args: []parse.Node{identifier}})
+		}
+	case *parse.IfNode:
+		printMissingAsEmpty(tree, n.List)
+		printMissingAsEmpty(tree, n.ElseList)
+	case *parse.RangeNode:
+		printMissingAsEmpty(tree, n.List)
+		printMissingAsEmpty(tree, n.ElseList)
+	case *parse.WithNode:
+		printMissingAsEmpty(tree, n.List)
+		printMissingAsEmpty(tree, n.ElseList)
+	}
+}